@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache caches JSON values such as issues and users in files of a directory so that they are not looked up again
+// by later runs. Values expire after the TTL. A nil Cache caches nothing
+type Cache struct {
+	// Dir the directory of the cached values
+	Dir string
+
+	// TTL the time after which values expire. Values never expire if zero
+	TTL time.Duration
+
+	// Refresh ignores the cached values so that all values are looked up and cached again
+	Refresh bool
+}
+
+// entry the file of a cached value
+type entry struct {
+	Namespace string          `json:"namespace"`
+	Key       string          `json:"key"`
+	Time      time.Time       `json:"time"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// New creates a cache in the directory or returns nil if the directory is empty
+func New(dir string, ttl time.Duration, refresh bool) *Cache {
+	if dir == "" {
+		return nil
+	}
+	return &Cache{
+		Dir:     dir,
+		TTL:     ttl,
+		Refresh: refresh,
+	}
+}
+
+// Get reads the cached value of the key in the namespace such as the URL of an issue tracker into value. Returns
+// false if there is no cached value, it has expired or the cache is refreshed. A cached null value is a negative
+// result such as an issue which does not exist
+func (c *Cache) Get(namespace, key string, value interface{}) (bool, error) {
+	if c == nil || c.Refresh {
+		return false, nil
+	}
+	path := c.path(namespace, key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read cache file %s: %w", path, err)
+	}
+	e := &entry{}
+	err = json.Unmarshal(data, e)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse cache file %s: %w", path, err)
+	}
+	if e.Namespace != namespace || e.Key != key {
+		return false, nil
+	}
+	if c.TTL > 0 && time.Since(e.Time) > c.TTL {
+		return false, nil
+	}
+	err = json.Unmarshal(e.Value, value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse cache file %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Put caches the value of the key in the namespace. Use a nil value to cache a negative result
+func (c *Cache) Put(namespace, key string, value interface{}) error {
+	if c == nil {
+		return nil
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the value of %s in %s: %w", key, namespace, err)
+	}
+	data, err := json.Marshal(&entry{
+		Namespace: namespace,
+		Key:       key,
+		Time:      time.Now(),
+		Value:     v,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the value of %s in %s: %w", key, namespace, err)
+	}
+	path := c.path(namespace, key)
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	// write a temporary file and rename it so that concurrent readers never see a partial file
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file in %s: %w", dir, err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to write cache file %s: %w", path, err)
+	}
+	return nil
+}
+
+// path returns the file of the key in the namespace. Namespaces and keys are hashed as they may contain any
+// characters such as the slashes of URLs
+func (c *Cache) path(namespace, key string) string {
+	ns := sha256.Sum256([]byte(namespace))
+	k := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(ns[:8]), hex.EncodeToString(k[:])+".json")
+}
@@ -0,0 +1,70 @@
+//go:build unit
+
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	c := cache.New(t.TempDir(), time.Hour, false)
+	require.NotNil(t, c)
+
+	issue := &scm.Issue{}
+	found, err := c.Get("https://github.com/jstrachan/foo", "1", &issue)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	err = c.Put("https://github.com/jstrachan/foo", "1", &scm.Issue{Number: 1, Title: "the login page is broken"})
+	require.NoError(t, err)
+	err = c.Put("https://github.com/jstrachan/foo", "2", nil)
+	require.NoError(t, err)
+
+	found, err = c.Get("https://github.com/jstrachan/foo", "1", &issue)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "the login page is broken", issue.Title)
+
+	found, err = c.Get("https://github.com/jstrachan/foo", "2", &issue)
+	require.NoError(t, err)
+	assert.True(t, found, "negative results are cached")
+	assert.Nil(t, issue)
+
+	found, err = c.Get("https://github.com/jstrachan/bar", "1", &issue)
+	require.NoError(t, err)
+	assert.False(t, found, "keys are cached per namespace")
+
+	refresh := cache.New(c.Dir, time.Hour, true)
+	found, err = refresh.Get("https://github.com/jstrachan/foo", "1", &issue)
+	require.NoError(t, err)
+	assert.False(t, found, "cached values are ignored when refreshing")
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := cache.New(t.TempDir(), 10*time.Millisecond, false)
+	err := c.Put("users", "jstrachan", &scm.User{Login: "jstrachan"})
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	user := &scm.User{}
+	found, err := c.Get("users", "jstrachan", &user)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestNilCache(t *testing.T) {
+	c := cache.New("", time.Hour, false)
+	assert.Nil(t, c)
+
+	err := c.Put("users", "jstrachan", &scm.User{Login: "jstrachan"})
+	require.NoError(t, err)
+	found, err := c.Get("users", "jstrachan", &scm.User{})
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,434 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	// GitHubIssueRegex the default regular expression for git provider issues such as #123
+	GitHubIssueRegex = regexp.MustCompile(`\B#\d+\b`)
+
+	// JIRAIssueRegex the default regular expression for Jira issues such as ABC-123
+	JIRAIssueRegex = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-\d+\b`)
+
+	// BugzillaIssueRegex the default regular expression for Bugzilla bugs such as bug 12345 or rhbz#12345
+	BugzillaIssueRegex = regexp.MustCompile(`(?i)\b(?:bug\s*#?|rhbz#)(\d+)\b`)
+
+	// TrelloIssueRegex the default regular expression for Trello cards such as trello#AbCd1234 or the card URL
+	// https://trello.com/c/AbCd1234
+	TrelloIssueRegex = regexp.MustCompile(`(?:\btrello\.com/c/|\btrello#)([A-Za-z0-9]{8}|[0-9a-f]{24})\b`)
+
+	// AzureIssueRegex the default regular expression for Azure DevOps work items such as AB#123
+	AzureIssueRegex = regexp.MustCompile(`\bAB#(\d+)\b`)
+)
+
+// The kinds of lookups in the summary of failed lookups
+const (
+	LookupIssue   = "issue"
+	LookupUser    = "user"
+	LookupRelease = "release"
+)
+
+// LookupFailure a lookup of an issue, user or release on the git provider or an issue tracker which failed even
+// after retrying so that the changelog may be incomplete
+type LookupFailure struct {
+	// Kind the kind of lookup such as issue
+	Kind string
+
+	// Key the issue key, user login or tag which was looked up
+	Key string
+
+	// Error the error message
+	Error string
+}
+
+// Options configures the generation of a changelog for a git repository.
+//
+// Only Dir, Git and GitURL are required. If no ScmClient is specified tags are not checked for releases and users
+// are not resolved. If no IssueProvider is specified issues are not looked up.
+type Options struct {
+	// Dir the directory of the git repository
+	Dir string
+
+	// PreviousRevision the revision to start the changelog from. Defaults to the previous tag
+	PreviousRevision string
+
+	// PreviousDate the date to start the changelog from in format 'MonthName dayNumber year'
+	PreviousDate string
+
+	// CurrentRevision the revision to end the changelog at. Defaults to the latest tag
+	CurrentRevision string
+
+	// TagPrefix the prefix to filter on when searching for version tags
+	TagPrefix string
+
+	// Version the version of the release. Defaults to the latest tag
+	Version string
+
+	// StatusPath the path of the deployment status file used to calculate dependency updates
+	StatusPath string
+
+	// PullRequestMode builds the changelog from the pull requests merged between the revisions instead of the
+	// commits. Requires a ScmClient
+	PullRequestMode bool
+
+	IncludeMergeCommits      bool
+	IncludePRChangelog       bool
+	FailIfFindCommits        bool
+	IgnoreTagsWithoutRelease bool
+
+	// ExcludeRegexps commits with messages matching any of these are left out
+	ExcludeRegexps []*regexp.Regexp
+
+	// IncludeRegexps if specified only commits with messages matching one of these are included
+	IncludeRegexps []*regexp.Regexp
+
+	// ResolvedIssuesOnly leaves out issues which are not closed or resolved
+	ResolvedIssuesOnly bool
+
+	GitIssueRegexp *regexp.Regexp
+
+	// JiraIssueRegexp the regular expression of Jira issue keys. Defaults to the keys of the project of the Jira
+	// issue provider or JIRAIssueRegex if there is no project
+	JiraIssueRegexp *regexp.Regexp
+
+	// BugzillaIssueRegexp the regular expression of Bugzilla bug references whose first group is the bug id.
+	// Defaults to BugzillaIssueRegex
+	BugzillaIssueRegexp *regexp.Regexp
+
+	// TrelloIssueRegexp the regular expression of Trello card references whose first group is the short link or ID
+	// of the card. Defaults to TrelloIssueRegex
+	TrelloIssueRegexp *regexp.Regexp
+
+	// AzureIssueRegexp the regular expression of Azure DevOps work item references whose first group is the work
+	// item ID. Defaults to AzureIssueRegex
+	AzureIssueRegexp *regexp.Regexp
+
+	// LinearIssueRegexp the regular expression of Linear issue identifiers. Defaults to the identifiers of the issues
+	// of the teams of the Linear issue provider
+	LinearIssueRegexp *regexp.Regexp
+
+	// IssueTimeout the maximum time of each lookup of one or more issues. No limit if zero
+	IssueTimeout time.Duration
+
+	// FailIfIncomplete fails if any issue, user or release could not be looked up. Otherwise the failed lookups are
+	// only logged
+	FailIfIncomplete bool
+
+	// Cache caches the issues and users which are looked up across runs. Optional
+	Cache *cache.Cache
+
+	// Concurrency the maximum number of issues, pull requests and users looked up at once. Defaults to 1
+	Concurrency int
+
+	// GitKind the kind of git provider such as gitlab which decides how missing releases are reported
+	GitKind string
+
+	Git           gitclient.Interface
+	GitURL        *giturl.GitRepository
+	ScmClient     *scm.Client
+	IssueProvider issues.IssueProvider
+	Generator     *gits.Generator
+
+	State State
+}
+
+// State the state while generating the changelog
+type State struct {
+	FoundIssueNames map[string]bool
+	LoggedIssueKind bool
+
+	// Issues the issues looked up in batches before enriching the commits indexed by key. Nil if not found
+	Issues map[string]*scm.Issue
+
+	// IssueErrors the errors looking up issues in batches indexed by key
+	IssueErrors map[string]error
+
+	// MergedPullRequests the IDs of the pull requests merged in the release in pull request mode. True once the
+	// entry of the pull request was added. References to them from other pull requests do not add them
+	MergedPullRequests map[string]bool
+
+	// Failures the lookups which failed
+	Failures []LookupFailure
+}
+
+// Result the result of generating a changelog
+type Result struct {
+	// PreviousRevision the revision the changelog starts from
+	PreviousRevision string
+
+	// CurrentRevision the revision the changelog ends at
+	CurrentRevision string
+
+	// Tag the git tag of the release
+	Tag string
+
+	// Version the version of the release without any tag prefix
+	Version string
+
+	// Spec the commits, issues, pull requests and dependency updates of the release
+	Spec *v1.ReleaseSpec
+
+	// Changelog the commits of the release parsed and grouped by type
+	Changelog *gits.Changelog
+
+	// Failures the lookups of issues, users and releases which failed so that the changelog may be incomplete
+	Failures []LookupFailure
+}
+
+// Generate generates the changelog for the revision range. Returns nil if there are no changes to generate a
+// changelog for such as when there is no previous commit or no git directory.
+func (o *Options) Generate(ctx context.Context) (*Result, error) {
+	if o.Generator == nil {
+		o.Generator = gits.NewGenerator()
+	}
+	if o.GitIssueRegexp == nil {
+		o.GitIssueRegexp = GitHubIssueRegex
+	}
+	trackers := issues.Providers(o.IssueProvider)
+	if o.JiraIssueRegexp == nil {
+		o.JiraIssueRegexp = JIRAIssueRegex
+		for _, t := range trackers {
+			if js, ok := t.(*issues.JiraService); ok && js.Project != "" {
+				o.JiraIssueRegexp = issues.JiraIssueKeyRegexp(js.Project)
+			}
+		}
+	}
+	if o.BugzillaIssueRegexp == nil {
+		o.BugzillaIssueRegexp = BugzillaIssueRegex
+	}
+	if o.TrelloIssueRegexp == nil {
+		o.TrelloIssueRegexp = TrelloIssueRegex
+	}
+	if o.AzureIssueRegexp == nil {
+		o.AzureIssueRegexp = AzureIssueRegex
+	}
+	if o.LinearIssueRegexp == nil {
+		for _, t := range trackers {
+			if ls, ok := t.(*issues.LinearService); ok {
+				o.LinearIssueRegexp = issues.LinearIssueKeyRegexp(ls.Teams...)
+			}
+		}
+	}
+	if composite, ok := o.IssueProvider.(*issues.CompositeIssueProvider); ok {
+		for _, t := range composite.Trackers {
+			if t.Regexp == nil {
+				t.Regexp = o.issueRegexp(t.Tracker)
+			}
+		}
+	}
+	dir := o.Dir
+	o.State.Failures = nil
+
+	previousRev, err := o.ResolvePreviousRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if previousRev == "" {
+		log.Logger().Info("no previous commit version found so change diff unavailable")
+		return nil, nil
+	}
+	currentRev, tagName, err := gits.GetCommitPointedToByLatestTag(o.Git, dir, o.TagPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if o.CurrentRevision != "" {
+		currentRev = o.CurrentRevision
+	}
+	prefix := "v"
+	if o.TagPrefix != "" {
+		prefix = o.TagPrefix
+	}
+	version := o.Version
+	if version != "" && version != tagName && prefix+version != tagName {
+		log.Logger().Warnf("version %s does not match the latest tag %s. Will tag head of default branch with version", info(version), info(tagName))
+		tagName = version
+	}
+	if version == "" {
+		version = tagName
+	}
+	version = strings.TrimPrefix(version, prefix)
+
+	log.Logger().Infof("Generating change log from git ref %s => %s", info(previousRev), info(currentRev))
+
+	gitDir, gitConfDir, err := gitclient.FindGitConfigDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if gitDir == "" || gitConfDir == "" {
+		log.Logger().Warnf("No git directory could be found from dir %s", dir)
+		return nil, nil
+	}
+
+	o.State.FoundIssueNames = map[string]bool{}
+	o.State.Issues = map[string]*scm.Issue{}
+	o.State.IssueErrors = map[string]error{}
+	o.State.MergedPullRequests = map[string]bool{}
+
+	commits, err := FetchCommits(gitDir, previousRev, currentRev)
+	if err != nil {
+		if o.FailIfFindCommits {
+			return nil, err
+		}
+		log.Logger().Warnf("failed to find git commits between revision %s and %s due to: %s", previousRev, currentRev, err.Error())
+	} else if log.Logger().Logger.IsLevelEnabled(logrus.DebugLevel) {
+		log.Logger().Debugf("Found commits:")
+		for k := range *commits {
+			commit := (*commits)[k]
+			log.Logger().Debugf("  commit %s", commit.Hash)
+			log.Logger().Debugf("  Author: %s <%s>", commit.Author.Name, commit.Author.Email)
+			log.Logger().Debugf("  Date: %s", commit.Committer.When.Format(time.ANSIC))
+			log.Logger().Debugf("      %s\n\n\n", commit.Message)
+		}
+	}
+
+	gitInfo := o.GitURL
+	spec := &v1.ReleaseSpec{
+		Version:       version,
+		GitOwner:      gitInfo.Organisation,
+		GitRepository: gitInfo.Name,
+		GitHTTPURL:    gitInfo.HttpsURL(),
+		GitCloneURL:   gitInfo.CloneURL,
+		Commits:       []v1.CommitSummary{},
+		Issues:        []v1.IssueSummary{},
+		PullRequests:  []v1.IssueSummary{},
+	}
+
+	var resolver *users.GitUserResolver
+	if o.ScmClient != nil {
+		resolver = &users.GitUserResolver{
+			GitProvider: o.ScmClient,
+			Cache:       o.Cache,
+		}
+	}
+	switch {
+	case commits == nil:
+	case o.PullRequestMode:
+		since, err := o.commitDate(previousRev)
+		if err != nil {
+			return nil, err
+		}
+		err = o.addPullRequests(ctx, spec, *commits, since, resolver)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		var messages []string
+		for k := range *commits {
+			if o.isCommitIncluded(&(*commits)[k]) {
+				messages = append(messages, (*commits)[k].Message)
+			}
+		}
+		o.prefetchIssues(ctx, messages, resolver)
+		for k := range *commits {
+			c := (*commits)[k]
+			o.addCommit(spec, &c, resolver)
+		}
+	}
+
+	spec.DependencyUpdates, err = o.getDependencyUpdates(previousRev)
+	if err != nil {
+		log.Logger().Warnf("failed to get dependency updates: %v", err)
+	}
+
+	if len(o.State.Failures) > 0 {
+		log.Logger().Warnf("The changelog may be incomplete as %d lookups failed:", len(o.State.Failures))
+		for _, f := range o.State.Failures {
+			log.Logger().Warnf("  %s %s: %s", f.Kind, f.Key, f.Error)
+		}
+		if o.FailIfIncomplete {
+			return nil, fmt.Errorf("the changelog is incomplete as %d lookups of issues, users or releases failed", len(o.State.Failures))
+		}
+	}
+
+	changelog := o.Generator.NewChangelog(spec)
+	changelog.Version = version
+	changelog.Tag = tagName
+	changelog.PreviousRevision = previousRev
+	changelog.CurrentRevision = currentRev
+
+	return &Result{
+		PreviousRevision: previousRev,
+		CurrentRevision:  currentRev,
+		Tag:              tagName,
+		Version:          version,
+		Spec:             spec,
+		Changelog:        changelog,
+		Failures:         o.State.Failures,
+	}, nil
+}
+
+// ResolvePreviousRevision returns the revision the changelog starts from.
+//
+// If no previous revision or date is specified the most recent tag before the latest one is used. When
+// IgnoreTagsWithoutRelease is enabled tags without a release on the git provider are skipped so that the changes
+// of failed release builds are not lost. If there are no previous tags the first commit is used.
+func (o *Options) ResolvePreviousRevision(ctx context.Context) (string, error) {
+	dir := o.Dir
+	previousRev := o.PreviousRevision
+	if previousRev != "" {
+		return previousRev, nil
+	}
+	var err error
+	if o.PreviousDate != "" {
+		previousRev, err = gits.GetRevisionBeforeDateText(o.Git, dir, o.PreviousDate)
+		if err != nil {
+			return "", fmt.Errorf("failed to find commits before date %s: %w", o.PreviousDate, err)
+		}
+		if previousRev != "" {
+			return previousRev, nil
+		}
+	}
+
+	tagList, err := gits.NTags(o.Git, dir, 11, o.TagPrefix)
+	if err != nil {
+		return "", fmt.Errorf("getting tags in %s: %w", dir, err)
+	}
+	if o.IgnoreTagsWithoutRelease && o.ScmClient != nil && o.ScmClient.Releases != nil {
+		fullName := scm.Join(o.GitURL.Organisation, o.GitURL.Name)
+		for n := 1; n < len(tagList); n++ {
+			previousTag := tagList[n][1]
+			// We ignore tags without releases so changelogs for failed release builds isn't skipped
+			// TODO: Should we care about the status of the release?
+			_, _, err = o.ScmClient.Releases.FindByTag(ctx, fullName, previousTag)
+			if err != nil {
+				if !isReleaseNotFound(err, o.GitKind) {
+					log.Logger().Warnf("failed to find the release of tag %s: %v", previousTag, err)
+					o.addFailure(LookupRelease, previousTag, err)
+				}
+				continue
+			}
+			previousRev, _, err = gits.GetCommitForTagSha(o.Git, dir, tagList[n][0], previousTag)
+			return previousRev, err
+		}
+	}
+	if len(tagList) > 1 {
+		// If no release was found use the first tag before current
+		previousRev, _, err = gits.GetCommitForTagSha(o.Git, dir, tagList[1][0], tagList[1][1])
+		return previousRev, err
+	}
+	// let's assume we are the first release
+	previousRev, err = gits.GetFirstCommitSha(o.Git, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to find first commit after we found no previous releaes: %w", err)
+	}
+	return previousRev, nil
+}
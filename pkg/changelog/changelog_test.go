@@ -0,0 +1,713 @@
+//go:build unit
+
+package changelog_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestRepository creates a git repository in a temporary directory with a commit for each message and
+// a tag for each non empty tag name. Each commit is a minute apart so that the tags sort by date.
+func createTestRepository(t *testing.T, commits [][2]string) (string, gitclient.Interface) {
+	dir := t.TempDir()
+	git := func(date time.Time, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=James Strachan",
+			"GIT_AUTHOR_EMAIL=jstrachan@example.com",
+			"GIT_COMMITTER_NAME=James Strachan",
+			"GIT_COMMITTER_EMAIL=jstrachan@example.com",
+			"GIT_AUTHOR_DATE="+date.Format(time.RFC3339),
+			"GIT_COMMITTER_DATE="+date.Format(time.RFC3339),
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, string(out))
+	}
+	date := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	git(date, "init", "-q")
+	for _, c := range commits {
+		date = date.Add(time.Minute)
+		err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(c[0]), 0o600)
+		require.NoError(t, err)
+		git(date, "add", "file.txt")
+		git(date, "commit", "-q", "-m", c[0])
+		if c[1] != "" {
+			git(date, "tag", c[1])
+		}
+	}
+	return dir, cli.NewCLIClient("", cmdrunner.QuietCommandRunner)
+}
+
+func TestGenerate(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"feat: some feature", ""},
+		{"release 1.0.1", ""},
+		{"fix(cheese): some fix", "v1.1.0"},
+	})
+
+	o := &changelog.Options{
+		Dir:    dir,
+		Git:    g,
+		GitURL: &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	assert.Equal(t, "v1.1.0", result.Tag)
+	assert.Equal(t, "1.1.0", result.Version)
+	assert.NotEmpty(t, result.PreviousRevision)
+	assert.NotEmpty(t, result.CurrentRevision)
+	assert.Equal(t, "jstrachan", result.Spec.GitOwner)
+	require.Len(t, result.Spec.Commits, 3)
+
+	var titles []string
+	for _, group := range result.Changelog.Groups {
+		titles = append(titles, group.Title)
+	}
+	assert.Equal(t, []string{"New Features", "Bug Fixes", "Other Changes"}, titles)
+	assert.Equal(t, "cheese", result.Changelog.Groups[1].Entries[0].Scope)
+	assert.Equal(t, result.PreviousRevision, result.Changelog.PreviousRevision)
+}
+
+func TestGenerateExcludesAndIncludes(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"release 1.0.1", ""},
+		{"chore: updated dependency", ""},
+		{"feat: Cool new feature", ""},
+		{"fix: some fix", "v1.1.0"},
+	})
+
+	o := &changelog.Options{
+		Dir:            dir,
+		Git:            g,
+		GitURL:         &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ExcludeRegexps: []*regexp.Regexp{regexp.MustCompile("^release "), regexp.MustCompile("^chore")},
+		IncludeRegexps: []*regexp.Regexp{regexp.MustCompile("^feat")},
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Spec.Commits, 1)
+	assert.Equal(t, "feat: Cool new feature\n", result.Spec.Commits[0].Message)
+}
+
+func TestGeneratePullRequestMode(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"feat: squashed feature (#1)\n\n* wip\n* more wip", ""},
+		{"direct push to main", ""},
+		{"fix: squashed fix (#2)", "v1.1.0"},
+	})
+	sha := func(rev string) string {
+		out, err := g.Command(dir, "rev-parse", rev)
+		require.NoError(t, err)
+		return out
+	}
+
+	scmClient, fakeData := fake.NewDefault()
+	repo := scm.Repository{Namespace: "jstrachan", Name: "foo", FullName: "jstrachan/foo"}
+	fakeData.PullRequests[1] = &scm.PullRequest{
+		Number:   1,
+		Title:    "feat: a new feature",
+		Body:     "fixes #3",
+		Labels:   []*scm.Label{{Name: "enhancement"}},
+		Closed:   true,
+		Merged:   true,
+		MergeSha: sha("HEAD~2"),
+		Base:     scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Author:   scm.User{Login: "jstrachan"},
+		Link:     "https://github.com/jstrachan/foo/pull/1",
+		Updated:  time.Now(),
+	}
+	fakeData.PullRequests[2] = &scm.PullRequest{
+		Number:   2,
+		Title:    "fix: a bug",
+		Closed:   true,
+		Merged:   true,
+		MergeSha: sha("HEAD"),
+		Base:     scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Author:   scm.User{Login: "rawlingsj"},
+		Link:     "https://github.com/jstrachan/foo/pull/2",
+		Updated:  time.Now(),
+	}
+	fakeData.PullRequests[4] = &scm.PullRequest{
+		Number:  4,
+		Title:   "feat: closed without merging",
+		Closed:  true,
+		Base:    scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Updated: time.Now(),
+	}
+
+	o := &changelog.Options{
+		Dir:             dir,
+		Git:             g,
+		GitURL:          &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ScmClient:       scmClient,
+		PullRequestMode: true,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	require.Len(t, result.Spec.Commits, 2)
+	assert.Equal(t, "fix: a bug", result.Spec.Commits[0].Message)
+	assert.Equal(t, "feat: a new feature", result.Spec.Commits[1].Message)
+	require.Len(t, result.Spec.PullRequests, 2)
+
+	require.Len(t, result.Changelog.Groups, 2)
+	feature := result.Changelog.Groups[0].Entries[0]
+	assert.Equal(t, "a new feature", feature.Description)
+	assert.Equal(t, []string{"enhancement"}, feature.Labels)
+	require.NotNil(t, feature.PullRequest)
+	assert.Equal(t, "1", feature.PullRequest.ID)
+	assert.Equal(t, "jstrachan", feature.Author.Login)
+}
+
+func TestGeneratePullRequestModeReferencedPullRequest(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"feat: squashed feature (#1)", ""},
+		{"fix: squashed fix (#2)", "v1.1.0"},
+	})
+	sha := func(rev string) string {
+		out, err := g.Command(dir, "rev-parse", rev)
+		require.NoError(t, err)
+		return out
+	}
+
+	scmClient, fakeData := fake.NewDefault()
+	repo := scm.Repository{Namespace: "jstrachan", Name: "foo", FullName: "jstrachan/foo"}
+	fakeData.PullRequests[1] = &scm.PullRequest{
+		Number:   1,
+		Title:    "feat: a new feature",
+		Closed:   true,
+		Merged:   true,
+		MergeSha: sha("HEAD~1"),
+		Base:     scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Author:   scm.User{Login: "jstrachan"},
+		Link:     "https://github.com/jstrachan/foo/pull/1",
+		Updated:  time.Now(),
+	}
+	fakeData.PullRequests[2] = &scm.PullRequest{
+		Number:   2,
+		Title:    "fix: a bug in the new feature",
+		Body:     "follow up to #1",
+		Closed:   true,
+		Merged:   true,
+		MergeSha: sha("HEAD"),
+		Base:     scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Author:   scm.User{Login: "rawlingsj"},
+		Link:     "https://github.com/jstrachan/foo/pull/2",
+		Updated:  time.Now(),
+	}
+	fakeData.Issues[1] = []*scm.Issue{{
+		Number:      1,
+		Title:       "feat: a new feature",
+		Link:        "https://github.com/jstrachan/foo/pull/1",
+		Author:      scm.User{Login: "jstrachan"},
+		PullRequest: &scm.PullRequest{Number: 1},
+	}}
+	tracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+	require.NoError(t, err)
+
+	o := &changelog.Options{
+		Dir:             dir,
+		Git:             g,
+		GitURL:          &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ScmClient:       scmClient,
+		IssueProvider:   tracker,
+		PullRequestMode: true,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	require.Len(t, result.Spec.Commits, 2, "the pull request referenced by another one keeps its own entry")
+	assert.Equal(t, "fix: a bug in the new feature", result.Spec.Commits[0].Message)
+	assert.Equal(t, []string{"2", "1"}, result.Spec.Commits[0].IssueIDs)
+	assert.Equal(t, "feat: a new feature", result.Spec.Commits[1].Message)
+	var ids []string
+	for _, pr := range result.Spec.PullRequests {
+		ids = append(ids, pr.ID)
+	}
+	assert.Equal(t, []string{"2", "1"}, ids)
+}
+
+// pagedPullRequestService lists fixed pages of pull requests sorted by creation like GitHub whatever the time they
+// were updated and returns the commits of pull requests
+type pagedPullRequestService struct {
+	scm.PullRequestService
+	pages   [][]*scm.PullRequest
+	commits map[int][]*scm.Commit
+}
+
+func (s *pagedPullRequestService) List(_ context.Context, _ string, opts *scm.PullRequestListOptions) ([]*scm.PullRequest, *scm.Response, error) {
+	if opts.Page > len(s.pages) {
+		return nil, &scm.Response{}, nil
+	}
+	return s.pages[opts.Page-1], &scm.Response{}, nil
+}
+
+func (s *pagedPullRequestService) ListCommits(_ context.Context, _ string, number int, _ *scm.ListOptions) ([]*scm.Commit, *scm.Response, error) {
+	return s.commits[number], &scm.Response{}, nil
+}
+
+func TestGeneratePullRequestModePagesAndRebases(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"feat: first rebased commit", ""},
+		{"feat: second rebased commit", ""},
+		{"fix: a bug (#7)", "v1.1.0"},
+	})
+	sha := func(rev string) string {
+		out, err := g.Command(dir, "rev-parse", rev)
+		require.NoError(t, err)
+		return out
+	}
+
+	scmClient, fakeData := fake.NewDefault()
+	repo := scm.Repository{Namespace: "jstrachan", Name: "foo", FullName: "jstrachan/foo"}
+	var oldPullRequests []*scm.PullRequest
+	for i := 1; i <= 100; i++ {
+		oldPullRequests = append(oldPullRequests, &scm.PullRequest{Number: 1000 + i, Title: "chore: abandoned", Closed: true})
+	}
+	rebased := &scm.PullRequest{
+		Number:   150,
+		Title:    "feat: a rebased feature",
+		Closed:   true,
+		Merged:   true,
+		MergeSha: sha("HEAD~1"),
+		Base:     scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Author:   scm.User{Login: "jstrachan"},
+		Created:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Updated:  time.Now(),
+	}
+	// the squashed pull request is not listed so it is found by the number in the commit subject
+	fakeData.PullRequests[7] = &scm.PullRequest{
+		Number:   7,
+		Title:    "fix: a bug",
+		Closed:   true,
+		Merged:   true,
+		MergeSha: sha("HEAD"),
+		Base:     scm.PullRequestBranch{Ref: "main", Repo: repo},
+		Author:   scm.User{Login: "rawlingsj"},
+	}
+	scmClient.PullRequests = &pagedPullRequestService{
+		PullRequestService: scmClient.PullRequests,
+		pages:              [][]*scm.PullRequest{oldPullRequests, {rebased}},
+		commits: map[int][]*scm.Commit{
+			150: {
+				{Sha: "1111111", Message: "feat: first rebased commit", Author: scm.Signature{Email: "jstrachan@example.com"}},
+				{Sha: "2222222", Message: "feat: second rebased commit", Author: scm.Signature{Email: "jstrachan@example.com"}},
+			},
+		},
+	}
+
+	o := &changelog.Options{
+		Dir:             dir,
+		Git:             g,
+		GitURL:          &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ScmClient:       scmClient,
+		PullRequestMode: true,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	var messages []string
+	for _, c := range result.Spec.Commits {
+		messages = append(messages, c.Message)
+	}
+	assert.Equal(t, []string{"fix: a bug", "feat: a rebased feature"}, messages, "pull requests after a page of older ones and the ones only referenced by commits are included")
+}
+
+func TestBugzillaIssueRegex(t *testing.T) {
+	var ids []string
+	for _, m := range changelog.BugzillaIssueRegex.FindAllStringSubmatch("fix: login (bug 123, Bug #456, rhbz#789) debug 1 #42", -1) {
+		ids = append(ids, m[1])
+	}
+	assert.Equal(t, []string{"123", "456", "789"}, ids)
+}
+
+func TestTrelloIssueRegex(t *testing.T) {
+	var ids []string
+	for _, m := range changelog.TrelloIssueRegex.FindAllStringSubmatch("feat: login (trello#AbCd1234, https://trello.com/c/XyZw9876/12-login-page, trello#5fefaa80c3a4c51d3e2c5f01) #42", -1) {
+		ids = append(ids, m[1])
+	}
+	assert.Equal(t, []string{"AbCd1234", "XyZw9876", "5fefaa80c3a4c51d3e2c5f01"}, ids)
+}
+
+func TestAzureIssueRegex(t *testing.T) {
+	var ids []string
+	for _, m := range changelog.AzureIssueRegex.FindAllStringSubmatch("fix: login (AB#123, fixes AB#4567) #42 TAB#8", -1) {
+		ids = append(ids, m[1])
+	}
+	assert.Equal(t, []string{"123", "4567"}, ids)
+}
+
+func TestGenerateCompositeIssueProvider(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page ABC-12 #45", "v1.1.0"},
+	})
+
+	var jiraQueries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/search", r.URL.Path)
+		jiraQueries = append(jiraQueries, r.URL.Query().Get("jql"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"issues": [{"key": "ABC-12", "fields": {"summary": "the login page is broken"}}]}`))
+	}))
+	defer server.Close()
+	jiraTracker, err := issues.CreateJiraIssueProvider(server.URL, "", "", "ABC", false)
+	require.NoError(t, err)
+
+	scmClient, fakeData := fake.NewDefault()
+	fakeData.Issues[45] = []*scm.Issue{{
+		Number: 45,
+		Title:  "login fails",
+		Link:   "https://github.com/jstrachan/foo/issues/45",
+		Author: scm.User{Login: "jstrachan"},
+	}}
+	gitTracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+	require.NoError(t, err)
+
+	tracker := issues.NewCompositeIssueProvider(&issues.PatternTracker{Tracker: jiraTracker}, &issues.PatternTracker{Tracker: gitTracker})
+	o := &changelog.Options{
+		Dir:           dir,
+		Git:           g,
+		GitURL:        &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ScmClient:     scmClient,
+		IssueProvider: tracker,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	assert.Equal(t, []string{`key in ("ABC-12")`}, jiraQueries, "only Jira keys are looked up in Jira")
+	urls := map[string]string{}
+	for _, issue := range result.Spec.Issues {
+		urls[issue.ID] = issue.URL
+	}
+	assert.Equal(t, map[string]string{
+		"ABC-12": server.URL + "/browse/ABC-12",
+		"45":     "https://github.com/jstrachan/foo/issues/45",
+	}, urls)
+	assert.Equal(t, []string{"ABC-12", "45"}, result.Spec.Commits[0].IssueIDs)
+
+	err = tracker.CreateIssueComment("45", "Released in version 1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/browse/ABC-12", tracker.IssueURL("ABC-12"))
+	assert.Len(t, jiraQueries, 1, "comments on git issues are not sent to Jira")
+	assert.Equal(t, []string{"jstrachan/foo#45:Released in version 1.1.0"}, fakeData.IssueCommentsAdded)
+}
+
+func TestGenerateCompositeIssueProviderCollidingKeys(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page bug #45 #45 bug #46", "v1.1.0"},
+	})
+
+	var bugzillaRequests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bugzillaRequests = append(bugzillaRequests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"id": 1}`))
+			return
+		}
+		id := filepath.Base(r.URL.Path)
+		_, _ = fmt.Fprintf(w, `{"bugs": [{"id": %s, "summary": "bug %s", "status": "RESOLVED", "is_open": false}]}`, id, id)
+	}))
+	defer server.Close()
+	bugzillaTracker, err := issues.CreateBugzillaIssueProvider(server.URL+"/", "", "", "")
+	require.NoError(t, err)
+
+	scmClient, fakeData := fake.NewDefault()
+	for _, n := range []int{45, 46} {
+		fakeData.Issues[n] = []*scm.Issue{{
+			Number: n,
+			Title:  fmt.Sprintf("git issue %d", n),
+			Link:   fmt.Sprintf("https://github.com/jstrachan/foo/issues/%d", n),
+			Author: scm.User{Login: "jstrachan"},
+		}}
+	}
+	gitTracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+	require.NoError(t, err)
+
+	tracker := issues.NewCompositeIssueProvider(&issues.PatternTracker{Tracker: bugzillaTracker}, &issues.PatternTracker{Tracker: gitTracker})
+	o := &changelog.Options{
+		Dir:           dir,
+		Git:           g,
+		GitURL:        &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ScmClient:     scmClient,
+		IssueProvider: tracker,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	titles := map[string]string{}
+	for _, issue := range result.Spec.Issues {
+		titles[issue.ID] = issue.Title
+	}
+	assert.Equal(t, map[string]string{
+		"bug-45": "bug 45",
+		"bug-46": "bug 46",
+		"45":     "git issue 45",
+	}, titles, "the git reference in bug #46 is not looked up")
+	assert.Equal(t, []string{"bug-45", "bug-46", "45"}, result.Spec.Commits[0].IssueIDs)
+
+	err = tracker.CreateIssueComment("bug-45", "Released in version 1.1.0")
+	require.NoError(t, err)
+	err = tracker.CreateIssueComment("45", "Released in version 1.1.0")
+	require.NoError(t, err)
+	assert.Contains(t, bugzillaRequests, "POST /rest/bug/45/comment")
+	assert.Equal(t, []string{"jstrachan/foo#45:Released in version 1.1.0"}, fakeData.IssueCommentsAdded)
+	assert.Equal(t, server.URL+"/show_bug.cgi?id=46", tracker.IssueURL("bug-46"))
+	assert.Equal(t, "//fake.com/jstrachan/foo/issues/46", tracker.IssueURL("46"))
+}
+
+func TestGenerateIssueTrackerUsers(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page bug 12345", "v1.1.0"},
+	})
+
+	bugzilla := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"bugs": [{
+			"id": 12345,
+			"summary": "the login page is broken",
+			"is_open": false,
+			"creator_detail": {"name": "jstrachan@example.com", "real_name": "James Strachan", "email": "jstrachan@example.com"},
+			"assigned_to_detail": {"name": "rawlingsj@example.com", "real_name": "James Rawlings", "email": "rawlingsj@example.com"}
+		}]}`))
+	}))
+	defer bugzilla.Close()
+	tracker, err := issues.CreateBugzillaIssueProvider(bugzilla.URL+"/", "", "", "")
+	require.NoError(t, err)
+
+	var gitRequests []string
+	gitServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gitRequests = append(gitRequests, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer gitServer.Close()
+	scmClient, err := github.New(gitServer.URL)
+	require.NoError(t, err)
+
+	o := &changelog.Options{
+		Dir:           dir,
+		Git:           g,
+		GitURL:        &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		ScmClient:     scmClient,
+		IssueProvider: tracker,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.Len(t, result.Spec.Issues, 1)
+
+	issue := result.Spec.Issues[0]
+	require.NotNil(t, issue.User)
+	assert.Equal(t, "James Strachan", issue.User.Name)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "rawlingsj@example.com", issue.Assignees[0].Login)
+	assert.Equal(t, "James Rawlings", issue.Assignees[0].Name)
+	assert.Empty(t, gitRequests, "the users of other issue trackers are not looked up in the git provider")
+	assert.Empty(t, result.Failures)
+}
+
+func TestGenerateConcurrentLookups(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page #1 #2", ""},
+		{"feat: search #3 #1", ""},
+		{"fix: logout #5 #4 #6", "v1.1.0"},
+	})
+
+	generate := func(concurrency int) *v1.ReleaseSpec {
+		scmClient, fakeData := fake.NewDefault()
+		for n := 1; n <= 5; n++ {
+			fakeData.Issues[n] = []*scm.Issue{{
+				Number:    n,
+				Title:     fmt.Sprintf("issue %d", n),
+				Author:    scm.User{Login: "jstrachan", Name: "James Strachan"},
+				Assignees: []scm.User{{Login: fmt.Sprintf("user%d", n), Name: fmt.Sprintf("User %d", n)}},
+			}}
+			fakeData.Users = append(fakeData.Users, &scm.User{Login: fmt.Sprintf("user%d", n), Name: fmt.Sprintf("User %d", n), Email: fmt.Sprintf("user%d@example.com", n)})
+		}
+		fakeData.Users = append(fakeData.Users, &scm.User{Login: "jstrachan", Name: "James Strachan", Email: "jstrachan@example.com"})
+		tracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+		require.NoError(t, err)
+
+		o := &changelog.Options{
+			Dir:           dir,
+			Git:           g,
+			GitURL:        &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+			ScmClient:     scmClient,
+			IssueProvider: tracker,
+			Concurrency:   concurrency,
+		}
+		result, err := o.Generate(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		return result.Spec
+	}
+
+	expected := generate(1)
+	var ids []string
+	for _, issue := range expected.Issues {
+		ids = append(ids, issue.ID)
+		require.Len(t, issue.Assignees, 1)
+		assert.Equal(t, "user"+issue.ID+"@example.com", issue.Assignees[0].Email)
+	}
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4", "5"}, ids, "each issue is added once")
+
+	for i := 0; i < 5; i++ {
+		actual := generate(8)
+		assert.Equal(t, expected.Issues, actual.Issues, "issues are in the same order as without concurrency")
+		assert.Equal(t, expected.Commits, actual.Commits)
+	}
+}
+
+func TestGenerateCache(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page #1 #9", "v1.1.0"},
+	})
+	issueCache := cache.New(t.TempDir(), time.Hour, false)
+
+	generate := func(c *cache.Cache, fakeIssues map[int][]*scm.Issue) []string {
+		scmClient, fakeData := fake.NewDefault()
+		fakeData.Issues = fakeIssues
+		tracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+		require.NoError(t, err)
+		o := &changelog.Options{
+			Dir:           dir,
+			Git:           g,
+			GitURL:        &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+			ScmClient:     scmClient,
+			IssueProvider: tracker,
+			Cache:         c,
+		}
+		result, err := o.Generate(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		var titles []string
+		for _, issue := range result.Spec.Issues {
+			titles = append(titles, issue.Title)
+		}
+		return titles
+	}
+
+	assert.Equal(t, []string{"login fails"}, generate(issueCache, map[int][]*scm.Issue{
+		1: {{Number: 1, Title: "login fails"}},
+	}))
+	assert.Equal(t, []string{"login fails"}, generate(issueCache, map[int][]*scm.Issue{
+		9: {{Number: 9, Title: "created later"}},
+	}), "issues which were found or not found are not looked up again")
+
+	refresh := cache.New(issueCache.Dir, time.Hour, true)
+	assert.Equal(t, []string{"created later"}, generate(refresh, map[int][]*scm.Issue{
+		9: {{Number: 9, Title: "created later"}},
+	}), "all issues are looked up again when refreshing the cache")
+	assert.Equal(t, []string{"created later"}, generate(issueCache, map[int][]*scm.Issue{}), "refreshing updates the cache")
+}
+
+// failingIssueProvider an issue provider whose lookups fail such as when it is rate limited
+type failingIssueProvider struct {
+	issues.IssueProvider
+}
+
+func (p *failingIssueProvider) GetIssue(key string) (*scm.Issue, error) {
+	return nil, fmt.Errorf("rate limited looking up issue %s", key)
+}
+
+func (p *failingIssueProvider) HomeURL() string {
+	return "https://github.com/jstrachan/foo"
+}
+
+func TestGenerateFailedLookups(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page #12", "v1.1.0"},
+	})
+
+	o := &changelog.Options{
+		Dir:           dir,
+		Git:           g,
+		GitURL:        &giturl.GitRepository{Host: "github.com", Organisation: "jstrachan", Name: "foo"},
+		IssueProvider: &failingIssueProvider{},
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, []changelog.LookupFailure{{
+		Kind:  changelog.LookupIssue,
+		Key:   "12",
+		Error: "rate limited looking up issue 12",
+	}}, result.Failures)
+
+	o.FailIfIncomplete = true
+	_, err = o.Generate(context.Background())
+	assert.EqualError(t, err, "the changelog is incomplete as 1 lookups of issues, users or releases failed")
+}
+
+// forbiddenReleaseService reports missing releases as forbidden like older GitLab servers
+type forbiddenReleaseService struct {
+	scm.ReleaseService
+}
+
+func (s *forbiddenReleaseService) FindByTag(context.Context, string, string) (*scm.Release, *scm.Response, error) {
+	return nil, &scm.Response{Status: http.StatusForbidden}, fmt.Errorf("403 Forbidden")
+}
+
+func TestGenerateGitLabTagsWithoutRelease(t *testing.T) {
+	dir, g := createTestRepository(t, [][2]string{
+		{"initial commit", "v1.0.0"},
+		{"fix: the login page", "v1.1.0"},
+	})
+	scmClient, _ := fake.NewDefault()
+	scmClient.Releases = &forbiddenReleaseService{}
+
+	o := &changelog.Options{
+		Dir:                      dir,
+		Git:                      g,
+		GitURL:                   &giturl.GitRepository{Host: "gitlab.com", Organisation: "jstrachan", Name: "foo"},
+		GitKind:                  "gitlab",
+		ScmClient:                scmClient,
+		IgnoreTagsWithoutRelease: true,
+		FailIfIncomplete:         true,
+	}
+	result, err := o.Generate(context.Background())
+	require.NoError(t, err, "tags without a release are not failed lookups")
+	require.NotNil(t, result)
+	assert.Empty(t, result.Failures)
+}
@@ -0,0 +1,99 @@
+package changelog
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/releasereport"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+func (o *Options) getDependencyUpdates(previousRev string) ([]v1.DependencyUpdate, error) {
+	if o.StatusPath == "" {
+		return nil, nil
+	}
+	dir := o.Dir
+	absStatusPath := filepath.Join(dir, o.StatusPath)
+	releasesExists, err := files.FileExists(absStatusPath)
+	if err != nil {
+		log.Logger().Debugf("fail to check if %s exists", absStatusPath)
+		return nil, nil
+	}
+	if !releasesExists {
+		log.Logger().Debugf("file %s doesn't exists", absStatusPath)
+		return nil, nil
+	}
+	previousReleasesBlob, err := o.Git.Command(dir, "cat-file", "blob", previousRev+":"+o.StatusPath)
+	if err != nil {
+		return nil, fmt.Errorf("fail to check if %s exists for %s: %w", o.StatusPath, previousRev, err)
+	}
+	var previousReleases []*releasereport.NamespaceReleases
+	err = yaml.Unmarshal([]byte(previousReleasesBlob), &previousReleases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal previous releases %s: %w", previousRev, err)
+	}
+
+	var currentReleases []*releasereport.NamespaceReleases
+	err = yamls.LoadFile(absStatusPath, &currentReleases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", o.StatusPath, err)
+	}
+
+	previousReleasesMap := makeReleaseMap(&previousReleases)
+	updates := make([]v1.DependencyUpdate, 0)
+
+	for _, nsr := range currentReleases {
+		prevReleases, nsexisted := previousReleasesMap[nsr.Namespace]
+		if !nsexisted {
+			prevReleases = make(map[string]string)
+		}
+		for _, release := range nsr.Releases {
+			prevRel, relexisted := prevReleases[release.ReleaseName]
+			if relexisted {
+				delete(prevReleases, release.ReleaseName)
+			}
+			if prevRel != release.Version {
+				url := release.RepositoryURL
+				if url == "" {
+					url = release.ApplicationURL
+				}
+				updates = append(updates, v1.DependencyUpdate{
+					DependencyUpdateDetails: v1.DependencyUpdateDetails{
+						Component:   release.ReleaseName,
+						URL:         url,
+						FromVersion: prevRel,
+						ToVersion:   release.Version,
+					},
+				})
+			}
+		}
+	}
+
+	for _, nsr := range previousReleasesMap {
+		for name, release := range nsr {
+			updates = append(updates, v1.DependencyUpdate{
+				DependencyUpdateDetails: v1.DependencyUpdateDetails{
+					Component:   name,
+					FromVersion: release,
+				},
+			})
+		}
+	}
+
+	return updates, nil
+}
+
+func makeReleaseMap(namespaceReleases *[]*releasereport.NamespaceReleases) map[string]map[string]string {
+	res := make(map[string]map[string]string)
+	for _, nsr := range *namespaceReleases {
+		res[nsr.Namespace] = make(map[string]string)
+		for _, release := range nsr.Releases {
+			res[nsr.Namespace][release.ReleaseName] = release.Version
+		}
+	}
+	return res
+}
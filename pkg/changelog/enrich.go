@@ -0,0 +1,575 @@
+package changelog
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+func (o *Options) addCommit(spec *v1.ReleaseSpec, commit *object.Commit, resolver *users.GitUserResolver) {
+	if !o.isCommitIncluded(commit) {
+		return
+	}
+	url := ""
+	branch := "master"
+
+	var author, committer *v1.UserDetails
+	var err error
+	sha := commit.Hash.String()
+	if commit.Author.Email != "" && commit.Author.Name != "" {
+		author, err = resolver.GitSignatureAsUser(&commit.Author)
+		if err != nil {
+			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git author %s: %v", commit.Author, err)
+			o.addFailure(LookupUser, commit.Author.Email, err)
+		}
+	}
+	if commit.Committer.Email != "" && commit.Committer.Name != "" {
+		committer, err = resolver.GitSignatureAsUser(&commit.Committer)
+		if err != nil {
+			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git committer %s: %v", commit.Committer, err)
+			o.addFailure(LookupUser, commit.Committer.Email, err)
+		}
+	}
+	commitSummary := v1.CommitSummary{
+		Message:   commit.Message,
+		URL:       url,
+		SHA:       sha,
+		Author:    author,
+		Branch:    branch,
+		Committer: committer,
+	}
+
+	o.addIssuesAndPullRequests(spec, &commitSummary, commit.Message, resolver)
+	if o.IncludeMergeCommits || len(commit.ParentHashes) <= 1 {
+		spec.Commits = append(spec.Commits, commitSummary)
+	}
+}
+
+// isCommitIncluded returns false if the commit is a merge commit which is left out or its message is not included
+func (o *Options) isCommitIncluded(commit *object.Commit) bool {
+	if !o.IncludeMergeCommits && !o.IncludePRChangelog && len(commit.ParentHashes) > 1 {
+		return false
+	}
+	return o.isIncluded(commit.Message)
+}
+
+// isIncluded returns false if the commit message matches any of the exclude rules or if include rules are
+// specified and none of them match
+func (o *Options) isIncluded(message string) bool {
+	for _, r := range o.ExcludeRegexps {
+		if r.MatchString(message) {
+			return false
+		}
+	}
+	if len(o.IncludeRegexps) == 0 {
+		return true
+	}
+	for _, r := range o.IncludeRegexps {
+		if r.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Options) addIssuesAndPullRequests(spec *v1.ReleaseSpec, commit *v1.CommitSummary, message string, resolver *users.GitUserResolver) {
+	tracker := o.IssueProvider
+	if tracker == nil {
+		return
+	}
+
+	if !o.State.LoggedIssueKind {
+		o.State.LoggedIssueKind = true
+		var kinds []string
+		for _, t := range issues.Providers(tracker) {
+			kinds = append(kinds, issues.GetIssueProvider(t))
+		}
+		log.Logger().Infof("Finding issues in commit messages using %s format", strings.Join(kinds, ", "))
+	}
+	for _, ref := range o.issueReferences(message) {
+		o.addIssueOrPullRequest(spec, commit, ref.tracker, ref.key, resolver)
+	}
+}
+
+// trackerPatterns returns the issue trackers together with the regular expressions of the references to their issues
+func (o *Options) trackerPatterns() []*issues.PatternTracker {
+	tracker := o.IssueProvider
+	if tracker == nil {
+		return nil
+	}
+	composite, ok := tracker.(*issues.CompositeIssueProvider)
+	if !ok {
+		return []*issues.PatternTracker{{Tracker: tracker, Regexp: o.issueRegexp(tracker)}}
+	}
+	var answer []*issues.PatternTracker
+	for _, t := range composite.Trackers {
+		regex := t.Regexp
+		if regex == nil {
+			regex = o.issueRegexp(t.Tracker)
+		}
+		answer = append(answer, &issues.PatternTracker{Tracker: t.Tracker, Regexp: regex})
+	}
+	return answer
+}
+
+// issueReference a reference to an issue of a tracker in a message
+type issueReference struct {
+	tracker    issues.IssueProvider
+	key        string
+	start, end int
+}
+
+// issueReferences returns the references to issues in the message ordered by tracker. If the regular expression of
+// a tracker has a group the first group is the issue key otherwise the whole match without any leading #. Where the
+// references of trackers overlap such as bug #123 which also contains the git reference #123 only the leftmost
+// longest reference is used
+func (o *Options) issueReferences(message string) []issueReference {
+	var refs []issueReference
+	for _, t := range o.trackerPatterns() {
+		if t.Regexp == nil {
+			continue
+		}
+		for _, match := range t.Regexp.FindAllStringSubmatchIndex(message, -1) {
+			key := strings.TrimPrefix(message[match[0]:match[1]], "#")
+			if len(match) > 3 && match[2] >= 0 {
+				key = message[match[2]:match[3]]
+			}
+			refs = append(refs, issueReference{tracker: t.Tracker, key: key, start: match[0], end: match[1]})
+		}
+	}
+	order := make([]int, len(refs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := refs[order[i]], refs[order[j]]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		return a.end > b.end
+	})
+	used := make([]bool, len(refs))
+	end := 0
+	for _, i := range order {
+		if refs[i].start >= end {
+			used[i] = true
+			end = refs[i].end
+		}
+	}
+	var answer []issueReference
+	for i := range refs {
+		if used[i] {
+			answer = append(answer, refs[i])
+		}
+	}
+	return answer
+}
+
+// issueID returns the ID of the issue of the key of the tracker which is unique across the trackers of a composite
+// issue provider
+func (o *Options) issueID(tracker issues.IssueProvider, key string) string {
+	if composite, ok := o.IssueProvider.(*issues.CompositeIssueProvider); ok && tracker != o.IssueProvider {
+		return composite.IssueID(tracker, key)
+	}
+	return key
+}
+
+// issueBatchSize the maximum number of issues looked up by a worker from trackers which look up several issues at once
+const issueBatchSize = 100
+
+// issueBatch the keys of issues of a tracker looked up by a worker
+type issueBatch struct {
+	tracker issues.IssueProvider
+	keys    []string
+	found   map[string]*scm.Issue
+	err     error
+}
+
+// prefetchIssues looks up the issues referenced in the messages and the users of the issues with Concurrency workers
+// so that enriching the commits does not have to wait for each lookup in turn. The issues are remembered by ID in
+// the same way as when enriching the commits. Trackers which look up several issues at once get batches of keys and
+// other trackers a key at a time
+func (o *Options) prefetchIssues(ctx context.Context, messages []string, resolver *users.GitUserResolver) {
+	if o.State.Issues == nil {
+		o.State.Issues = map[string]*scm.Issue{}
+	}
+	if o.State.IssueErrors == nil {
+		o.State.IssueErrors = map[string]error{}
+	}
+	claimed := map[string]bool{}
+	var trackers []issues.IssueProvider
+	keysByTracker := map[issues.IssueProvider][]string{}
+	for _, message := range messages {
+		for _, ref := range o.issueReferences(message) {
+			id := o.issueID(ref.tracker, ref.key)
+			if _, found := o.State.FoundIssueNames[id]; found || claimed[id] || o.isMergedPullRequest(ref.tracker, id) {
+				continue
+			}
+			claimed[id] = true
+			if keysByTracker[ref.tracker] == nil {
+				trackers = append(trackers, ref.tracker)
+			}
+			keysByTracker[ref.tracker] = append(keysByTracker[ref.tracker], ref.key)
+		}
+	}
+	var batches []*issueBatch
+	for _, tracker := range trackers {
+		keys := o.uncachedIssues(tracker, keysByTracker[tracker])
+		size := 1
+		if issues.LooksUpBatches(tracker) {
+			size = issueBatchSize
+		}
+		for len(keys) > 0 {
+			n := size
+			if n > len(keys) {
+				n = len(keys)
+			}
+			batches = append(batches, &issueBatch{tracker: tracker, keys: keys[:n]})
+			keys = keys[n:]
+		}
+	}
+	o.forEach(len(batches), func(i int) {
+		b := batches[i]
+		bctx := ctx
+		if o.IssueTimeout > 0 {
+			var cancel context.CancelFunc
+			bctx, cancel = context.WithTimeout(ctx, o.IssueTimeout)
+			defer cancel()
+		}
+		log.Logger().Debugf("Looking up issues %s in issue tracker %s", strings.Join(b.keys, ", "), b.tracker.HomeURL())
+		b.found, b.err = o.getIssues(bctx, b.tracker, b.keys)
+	})
+
+	for _, b := range batches {
+		o.addIssueBatch(b)
+		for _, key := range b.keys {
+			if issue, ok := o.State.Issues[o.issueID(b.tracker, key)]; ok {
+				o.cacheIssue(b.tracker, key, issue)
+			}
+		}
+	}
+
+	var issueUsers []scm.User
+	for _, tracker := range trackers {
+		for _, key := range keysByTracker[tracker] {
+			issue := o.State.Issues[o.issueID(tracker, key)]
+			if issue == nil {
+				continue
+			}
+			// the users of other issue trackers are not git provider users so they are not resolved
+			if issues.GetIssueProvider(tracker) == issues.Git {
+				issueUsers = append(issueUsers, issue.Author)
+				issueUsers = append(issueUsers, issue.Assignees...)
+			}
+		}
+	}
+	o.prefetchUsers(resolver, issueUsers)
+}
+
+// issueCacheNamespace returns the namespace of the issues of the tracker in the Cache. External trackers use their
+// configured name rather than running their command for the home URL
+func issueCacheNamespace(tracker issues.IssueProvider) string {
+	if external, ok := tracker.(*issues.ExternalService); ok {
+		return "issues/external/" + external.Name
+	}
+	return "issues/" + tracker.HomeURL()
+}
+
+// uncachedIssues adds the cached issues of the tracker to the state and returns the keys of the other issues
+func (o *Options) uncachedIssues(tracker issues.IssueProvider, keys []string) []string {
+	if o.Cache == nil || o.Cache.Refresh {
+		return keys
+	}
+	namespace := issueCacheNamespace(tracker)
+	var answer []string
+	for _, key := range keys {
+		var issue *scm.Issue
+		found, err := o.Cache.Get(namespace, key, &issue)
+		if err != nil {
+			log.Logger().Debugf("failed to read issue %s from the cache: %v", key, err)
+		}
+		if !found {
+			answer = append(answer, key)
+			continue
+		}
+		o.State.Issues[o.issueID(tracker, key)] = issue
+		if composite, ok := o.IssueProvider.(*issues.CompositeIssueProvider); ok && issue != nil {
+			composite.SetOwner(key, tracker)
+		}
+	}
+	if len(answer) < len(keys) {
+		log.Logger().Infof("Using %d cached issues of issue tracker %s", len(keys)-len(answer), tracker.HomeURL())
+	}
+	return answer
+}
+
+// cacheIssue caches the issue of the key or that it does not exist
+func (o *Options) cacheIssue(tracker issues.IssueProvider, key string, issue *scm.Issue) {
+	if o.Cache == nil {
+		return
+	}
+	err := o.Cache.Put(issueCacheNamespace(tracker), key, issue)
+	if err != nil {
+		log.Logger().Warnf("failed to cache issue %s: %v", key, err)
+	}
+}
+
+// addIssueBatch adds the issues and errors of the batch to the state. If the whole batch failed for another reason
+// than a deadline the issues are left to be looked up one at a time
+func (o *Options) addIssueBatch(b *issueBatch) {
+	var errs issues.IssueErrors
+	switch {
+	case b.err == nil:
+	case errors.As(b.err, &errs):
+		for k, v := range errs {
+			o.State.IssueErrors[o.issueID(b.tracker, k)] = v
+		}
+	case errors.Is(b.err, context.DeadlineExceeded) || errors.Is(b.err, context.Canceled):
+		for _, key := range b.keys {
+			if b.found[key] == nil {
+				o.State.IssueErrors[o.issueID(b.tracker, key)] = b.err
+			}
+		}
+	default:
+		log.Logger().Warnf("Failed to look up %d issues in issue tracker %s so looking them up one at a time: %s", len(b.keys), b.tracker.HomeURL(), b.err)
+		for k, v := range b.found {
+			o.State.Issues[o.issueID(b.tracker, k)] = v
+		}
+		return
+	}
+	for _, key := range b.keys {
+		id := o.issueID(b.tracker, key)
+		if o.State.IssueErrors[id] == nil {
+			o.State.Issues[id] = b.found[key]
+		}
+	}
+}
+
+// prefetchUsers resolves the distinct users with Concurrency workers so that resolving them again when enriching
+// the commits uses the results remembered by the resolver
+func (o *Options) prefetchUsers(resolver *users.GitUserResolver, gitUsers []scm.User) {
+	if resolver == nil {
+		return
+	}
+	seen := map[string]bool{}
+	var distinct []scm.User
+	for k := range gitUsers {
+		u := gitUsers[k]
+		if u.Login == "" || u.Name == "" {
+			// resolved without calling the git provider
+			continue
+		}
+		id := u.Login + "\x00" + u.Name
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, u)
+		}
+	}
+	o.forEach(len(distinct), func(i int) {
+		_, err := resolver.Resolve(&distinct[i])
+		if err != nil {
+			log.Logger().Debugf("failed to resolve user %s: %v", distinct[i].Login, err)
+		}
+	})
+}
+
+// issueRegexp returns the regular expression of the references to issues of the tracker
+func (o *Options) issueRegexp(tracker issues.IssueProvider) *regexp.Regexp {
+	switch issues.GetIssueProvider(tracker) {
+	case issues.Jira:
+		return o.JiraIssueRegexp
+	case issues.Bugzilla:
+		return o.BugzillaIssueRegexp
+	case issues.Trello:
+		return o.TrelloIssueRegexp
+	case issues.Azure:
+		return o.AzureIssueRegexp
+	case issues.Linear:
+		// Linear identifiers look like Jira keys so only the ones of the configured teams are looked up
+		return o.LinearIssueRegexp
+	case issues.External:
+		return tracker.(*issues.ExternalService).Regexp
+	default:
+		return o.GitIssueRegexp
+	}
+}
+
+// getIssue looks up the issue in the tracker. If the issue provider combines several trackers it remembers the
+// tracker of the issue so that comments and fix versions go to the right tracker
+func (o *Options) getIssue(tracker issues.IssueProvider, key string) (*scm.Issue, error) {
+	if composite, ok := o.IssueProvider.(*issues.CompositeIssueProvider); ok && tracker != o.IssueProvider {
+		return composite.GetIssueFrom(tracker, key)
+	}
+	return tracker.GetIssue(key)
+}
+
+// getIssues looks up the issues in the tracker in batches. If the issue provider combines several trackers it
+// remembers the tracker of the issues
+func (o *Options) getIssues(ctx context.Context, tracker issues.IssueProvider, keys []string) (map[string]*scm.Issue, error) {
+	if composite, ok := o.IssueProvider.(*issues.CompositeIssueProvider); ok && tracker != o.IssueProvider {
+		return composite.GetIssuesFrom(ctx, tracker, keys)
+	}
+	return issues.AsBatchIssueProvider(tracker).GetIssues(ctx, keys)
+}
+
+// lookupIssue returns the issue looked up by prefetchIssues or otherwise looks it up in the tracker
+func (o *Options) lookupIssue(tracker issues.IssueProvider, key string) (*scm.Issue, error) {
+	id := o.issueID(tracker, key)
+	if err, ok := o.State.IssueErrors[id]; ok {
+		return nil, err
+	}
+	if issue, ok := o.State.Issues[id]; ok {
+		return issue, nil
+	}
+	issue, err := o.getIssue(tracker, key)
+	if err == nil {
+		o.cacheIssue(tracker, key, issue)
+	}
+	return issue, err
+}
+
+// addIssueOrPullRequest looks up the issue of the key of the tracker and adds it to the release unless it was
+// already added. Issues are added to the release and commit by their ID
+func (o *Options) addIssueOrPullRequest(spec *v1.ReleaseSpec, commit *v1.CommitSummary, tracker issues.IssueProvider, key string, resolver *users.GitUserResolver) {
+	result := o.issueID(tracker, key)
+	if o.isMergedPullRequest(tracker, result) {
+		// the pull request gets its own entry
+		commit.IssueIDs = stringhelpers.EnsureStringArrayContains(commit.IssueIDs, result)
+		return
+	}
+	if issueExists, ok := o.State.FoundIssueNames[result]; ok {
+		if issueExists {
+			commit.IssueIDs = stringhelpers.EnsureStringArrayContains(commit.IssueIDs, result)
+		}
+		return
+	}
+	o.State.FoundIssueNames[result] = false
+	issue, err := o.lookupIssue(tracker, key)
+	if err != nil {
+		log.Logger().Warnf("Failed to lookup issue %s in issue tracker %s due to %s", key, tracker.HomeURL(), err)
+		o.addFailure(LookupIssue, result, err)
+		return
+	}
+	if issue == nil {
+		log.Logger().Warnf("Failed to find issue %s for repository %s", key, tracker.HomeURL())
+		return
+	}
+	if o.ResolvedIssuesOnly && issue.PullRequest == nil && !issue.Closed {
+		log.Logger().Infof("Ignoring issue %s as it is not resolved", result)
+		return
+	}
+	o.State.FoundIssueNames[result] = true
+	commit.IssueIDs = append(commit.IssueIDs, result)
+
+	gitIssue := issues.GetIssueProvider(tracker) == issues.Git
+	var user *v1.UserDetails
+	if gitIssue {
+		user, err = resolver.Resolve(&issue.Author)
+		if err != nil {
+			log.Logger().Warnf("Failed to resolve user %v for issue %s repository %s", issue.Author, result, tracker.HomeURL())
+			o.addFailure(LookupUser, issue.Author.Login, err)
+		}
+	} else {
+		user = toUserDetails(&issue.Author)
+	}
+
+	var assignees []v1.UserDetails
+	switch {
+	case issue.Assignees == nil:
+		log.Logger().Warnf("Failed to find assignees for issue %s repository %s", result, tracker.HomeURL())
+	case gitIssue:
+		u, err := resolver.GitUserSliceAsUserDetailsSlice(issue.Assignees)
+		if err != nil {
+			log.Logger().Warnf("Failed to resolve Assignees %v for issue %s repository %s", issue.Assignees, result, tracker.HomeURL())
+			o.addFailure(LookupUser, logins(issue.Assignees), err)
+		}
+		assignees = u
+	default:
+		for k := range issue.Assignees {
+			assignees = append(assignees, *toUserDetails(&issue.Assignees[k]))
+		}
+	}
+
+	labels := toV1Labels(issue.Labels)
+	issueSummary := v1.IssueSummary{
+		ID:                result,
+		URL:               issue.Link,
+		Title:             issue.Title,
+		Body:              issue.Body,
+		User:              user,
+		CreationTimestamp: kube.ToMetaTime(&issue.Created),
+		Assignees:         assignees,
+		Labels:            labels,
+	}
+	state := issue.State
+	if state != "" {
+		issueSummary.State = state
+	}
+	if issue.PullRequest != nil {
+		spec.PullRequests = append(spec.PullRequests, issueSummary)
+	} else {
+		spec.Issues = append(spec.Issues, issueSummary)
+	}
+}
+
+// isMergedPullRequest returns true if the issue is a pull request merged in the release in pull request mode
+func (o *Options) isMergedPullRequest(tracker issues.IssueProvider, id string) bool {
+	_, ok := o.State.MergedPullRequests[id]
+	return ok && issues.GetIssueProvider(tracker) == issues.Git
+}
+
+// toUserDetails converts a user of an issue tracker which is not the git provider without looking it up
+func toUserDetails(u *scm.User) *v1.UserDetails {
+	return &v1.UserDetails{
+		Login:     u.Login,
+		Name:      u.Name,
+		Email:     u.Email,
+		URL:       u.Link,
+		AvatarURL: u.Avatar,
+	}
+}
+
+// toV1Labels converts git labels to IssueLabel
+func toV1Labels(labels []string) []v1.IssueLabel {
+	var answer []v1.IssueLabel
+	for _, label := range labels {
+		answer = append(answer, v1.IssueLabel{
+			Name: label,
+		})
+	}
+	return answer
+}
+
+// addFailure remembers a lookup which failed so that it is included in the summary of failed lookups
+func (o *Options) addFailure(kind, key string, err error) {
+	for _, f := range o.State.Failures {
+		if f.Kind == kind && f.Key == key {
+			return
+		}
+	}
+	o.State.Failures = append(o.State.Failures, LookupFailure{
+		Kind:  kind,
+		Key:   key,
+		Error: err.Error(),
+	})
+}
+
+// logins returns the logins of the users separated by commas
+func logins(gitUsers []scm.User) string {
+	var answer []string
+	for k := range gitUsers {
+		answer = append(answer, gitUsers[k].Login)
+	}
+	return strings.Join(answer, ", ")
+}
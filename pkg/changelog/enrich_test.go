@@ -0,0 +1,52 @@
+//go:build unit
+
+package changelog
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCommit(t *testing.T) {
+	o := &Options{
+		ExcludeRegexps: []*regexp.Regexp{regexp.MustCompile("^release ")},
+	}
+	release := v1.ReleaseSpec{
+		Commits:      []v1.CommitSummary{},
+		Issues:       []v1.IssueSummary{},
+		PullRequests: []v1.IssueSummary{},
+	}
+	o.addCommit(&release, &object.Commit{Message: "release 1.0.0", Hash: plumbing.NewHash("123")}, nil)
+	assert.Empty(t, release.Commits, "release commits are excluded")
+
+	o.ExcludeRegexps = []*regexp.Regexp{regexp.MustCompile("^chore")}
+	o.addCommit(&release, &object.Commit{Message: "chore: updated dependency", Hash: plumbing.NewHash("234")}, nil)
+	assert.Empty(t, release.Commits)
+	o.addCommit(&release, &object.Commit{Message: "feat: Cool new feature", Hash: plumbing.NewHash("234")}, nil)
+	assert.Len(t, release.Commits, 1)
+}
+
+func TestIsIncluded(t *testing.T) {
+	o := &Options{
+		ExcludeRegexps: []*regexp.Regexp{regexp.MustCompile("^release "), regexp.MustCompile("^chore")},
+	}
+	assert.False(t, o.isIncluded("release 1.0.0"))
+	assert.False(t, o.isIncluded("chore: updated dependency"))
+	assert.True(t, o.isIncluded("fix: some fix"))
+
+	o.IncludeRegexps = []*regexp.Regexp{regexp.MustCompile("^feat")}
+	assert.True(t, o.isIncluded("feat: Cool new feature"))
+	assert.False(t, o.isIncluded("fix: some fix"), "only included commits are added if there are include rules")
+}
+
+func TestIssueCacheNamespace(t *testing.T) {
+	tracker, err := issues.NewExternalIssueProvider("tickets", "", "does-not-exist", "", nil, nil, regexp.MustCompile(`TICKET-\d+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "issues/external/tickets", issueCacheNamespace(tracker), "the command of external trackers is not run for the namespace")
+}
@@ -0,0 +1,202 @@
+package changelog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+)
+
+// Bump the kind of increment of a semantic version
+type Bump int
+
+const (
+	// BumpNone no changes so the version stays the same
+	BumpNone Bump = iota
+	// BumpPatch only fixes or other changes
+	BumpPatch
+	// BumpMinor new features
+	BumpMinor
+	// BumpMajor breaking changes
+	BumpMajor
+)
+
+// maxVersionTags the number of most recent tags searched for the previous version
+const maxVersionTags = 100
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText marshals the bump as its name
+func (b Bump) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// NextVersion the next semantic version calculated from the commits since the previous version
+type NextVersion struct {
+	// PreviousTag the tag of the previous version or empty if there are no version tags
+	PreviousTag string `json:"previousTag,omitempty"`
+
+	// PreviousVersion the previous version
+	PreviousVersion string `json:"previousVersion"`
+
+	// Version the next version
+	Version string `json:"version"`
+
+	// Tag the tag to use for the next version
+	Tag string `json:"tag"`
+
+	// Bump the increment from the last release to the next version
+	Bump Bump `json:"bump"`
+}
+
+// CalculateBump returns the largest increment required by the commit messages. Breaking changes require a major
+// increment, new features a minor increment and any other commit a patch increment.
+func (o *Options) CalculateBump(messages []string) Bump {
+	if o.Generator == nil {
+		o.Generator = gits.NewGenerator()
+	}
+	bump := BumpNone
+	for _, message := range messages {
+		if !o.isIncluded(message) {
+			continue
+		}
+		b := BumpPatch
+		ci, bc := gits.ParseCommit(message)
+		if bc != nil || ci.Type == "break" {
+			b = BumpMajor
+		} else if o.Generator.CanonicalCommitType(ci.Type) == "feat" {
+			b = BumpMinor
+		}
+		if b > bump {
+			bump = b
+		}
+	}
+	return bump
+}
+
+// CalculateNextVersion calculates the next version from the conventional commits since the latest release tag.
+//
+// If prerelease is specified the next version is a pre-release such as 1.2.0-rc.1 whose number is incremented if
+// the latest tag is already a pre-release of the same version. Otherwise, the latest pre-release is promoted to a
+// release.
+func (o *Options) CalculateNextVersion(prerelease string) (*NextVersion, error) {
+	tagList, err := gits.NTags(o.Git, o.Dir, maxVersionTags, o.TagPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("getting tags in %s: %w", o.Dir, err)
+	}
+	prefix := "v"
+	if o.TagPrefix != "" {
+		prefix = o.TagPrefix
+	}
+
+	var latest, release *semver.Version
+	var latestTag, releaseTag string
+	for _, tag := range tagList {
+		v, err := semver.NewVersion(strings.TrimPrefix(tag[1], prefix))
+		if err != nil {
+			continue
+		}
+		if latest == nil {
+			latest = v
+			latestTag = tag[1]
+		}
+		if v.Prerelease() == "" {
+			release = v
+			releaseTag = tag[1]
+			break
+		}
+	}
+	if latest == nil {
+		latest = semver.New(0, 0, 0, "", "")
+	} else {
+		prefix = strings.TrimSuffix(latestTag, latest.Original())
+	}
+	base := release
+	if base == nil {
+		base = semver.New(0, 0, 0, "", "")
+	}
+
+	sinceLatest, err := o.commitMessagesSince(latestTag)
+	if err != nil {
+		return nil, err
+	}
+	answer := &NextVersion{
+		PreviousTag:     latestTag,
+		PreviousVersion: latest.String(),
+		Version:         latest.String(),
+		Tag:             latestTag,
+	}
+	if latestTag != "" && o.CalculateBump(sinceLatest) == BumpNone && (prerelease != "" || latest.Prerelease() == "") {
+		return answer, nil
+	}
+
+	sinceRelease := sinceLatest
+	if releaseTag != latestTag {
+		sinceRelease, err = o.commitMessagesSince(releaseTag)
+		if err != nil {
+			return nil, err
+		}
+	}
+	answer.Bump = o.CalculateBump(sinceRelease)
+	next := *base
+	switch answer.Bump {
+	case BumpMajor:
+		next = base.IncMajor()
+	case BumpMinor:
+		next = base.IncMinor()
+	case BumpPatch:
+		next = base.IncPatch()
+	}
+
+	if prerelease != "" {
+		n := 1
+		if latest.Prerelease() != "" && latest.Major() == next.Major() && latest.Minor() == next.Minor() && latest.Patch() == next.Patch() {
+			id, number, found := strings.Cut(latest.Prerelease(), ".")
+			if found && id == prerelease {
+				if i, err := strconv.Atoi(number); err == nil {
+					n = i + 1
+				}
+			}
+		}
+		next, err = next.SetPrerelease(prerelease + "." + strconv.Itoa(n))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pre-release %s: %w", prerelease, err)
+		}
+	}
+	answer.Version = next.String()
+	answer.Tag = prefix + answer.Version
+	return answer, nil
+}
+
+// commitMessagesSince returns the messages of the non merge commits since the tag or all commits if the tag is empty
+func (o *Options) commitMessagesSince(tag string) ([]string, error) {
+	args := []string{"log", "--no-merges", "--format=%B%x00"}
+	if tag != "" {
+		args = append(args, "refs/tags/"+tag+"..HEAD")
+	}
+	out, err := o.Git.Command(o.Dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find commits since %s: %w", tag, err)
+	}
+	var answer []string
+	for _, message := range strings.Split(out, "\x00") {
+		message = strings.TrimSpace(message)
+		if message != "" {
+			answer = append(answer, message)
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,88 @@
+//go:build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateNextVersion(t *testing.T) {
+	testCases := []struct {
+		name       string
+		commits    [][2]string
+		tagPrefix  string
+		prerelease string
+		expected   changelog.NextVersion
+	}{
+		{
+			name:     "no tags",
+			commits:  [][2]string{{"feat: first feature", ""}},
+			expected: changelog.NextVersion{PreviousVersion: "0.0.0", Version: "0.1.0", Tag: "v0.1.0", Bump: changelog.BumpMinor},
+		},
+		{
+			name:     "patch",
+			commits:  [][2]string{{"feat: first feature", "v1.2.3"}, {"fix: a bug", ""}, {"chore: tidy", ""}},
+			expected: changelog.NextVersion{PreviousTag: "v1.2.3", PreviousVersion: "1.2.3", Version: "1.2.4", Tag: "v1.2.4", Bump: changelog.BumpPatch},
+		},
+		{
+			name:     "minor",
+			commits:  [][2]string{{"feat: first feature", "v1.2.3"}, {"fix: a bug", ""}, {"feat: another feature", ""}},
+			expected: changelog.NextVersion{PreviousTag: "v1.2.3", PreviousVersion: "1.2.3", Version: "1.3.0", Tag: "v1.3.0", Bump: changelog.BumpMinor},
+		},
+		{
+			name:     "major",
+			commits:  [][2]string{{"feat: first feature", "v1.2.3"}, {"feat!: drop the old API", ""}},
+			expected: changelog.NextVersion{PreviousTag: "v1.2.3", PreviousVersion: "1.2.3", Version: "2.0.0", Tag: "v2.0.0", Bump: changelog.BumpMajor},
+		},
+		{
+			name:     "breaking change footer",
+			commits:  [][2]string{{"feat: first feature", "v1.2.3"}, {"fix: a bug\n\nBREAKING CHANGE: removes the flag", ""}},
+			expected: changelog.NextVersion{PreviousTag: "v1.2.3", PreviousVersion: "1.2.3", Version: "2.0.0", Tag: "v2.0.0", Bump: changelog.BumpMajor},
+		},
+		{
+			name:     "no changes",
+			commits:  [][2]string{{"feat: first feature", "v1.2.3"}},
+			expected: changelog.NextVersion{PreviousTag: "v1.2.3", PreviousVersion: "1.2.3", Version: "1.2.3", Tag: "v1.2.3"},
+		},
+		{
+			name:      "tag prefix",
+			commits:   [][2]string{{"feat: first feature", "myapp-1.0.0"}, {"fix: a bug", "v9.0.0"}, {"fix: another bug", ""}},
+			tagPrefix: "myapp-",
+			expected:  changelog.NextVersion{PreviousTag: "myapp-1.0.0", PreviousVersion: "1.0.0", Version: "1.0.1", Tag: "myapp-1.0.1", Bump: changelog.BumpPatch},
+		},
+		{
+			name:       "first prerelease",
+			commits:    [][2]string{{"feat: first feature", "v1.2.3"}, {"feat: another feature", ""}},
+			prerelease: "rc",
+			expected:   changelog.NextVersion{PreviousTag: "v1.2.3", PreviousVersion: "1.2.3", Version: "1.3.0-rc.1", Tag: "v1.3.0-rc.1", Bump: changelog.BumpMinor},
+		},
+		{
+			name:       "increment prerelease",
+			commits:    [][2]string{{"feat: first feature", "v1.2.3"}, {"feat: another feature", "v1.3.0-rc.1"}, {"fix: a bug", ""}},
+			prerelease: "rc",
+			expected:   changelog.NextVersion{PreviousTag: "v1.3.0-rc.1", PreviousVersion: "1.3.0-rc.1", Version: "1.3.0-rc.2", Tag: "v1.3.0-rc.2", Bump: changelog.BumpMinor},
+		},
+		{
+			name:     "promote prerelease",
+			commits:  [][2]string{{"feat: first feature", "v1.2.3"}, {"feat: another feature", "v1.3.0-rc.1"}},
+			expected: changelog.NextVersion{PreviousTag: "v1.3.0-rc.1", PreviousVersion: "1.3.0-rc.1", Version: "1.3.0", Tag: "v1.3.0", Bump: changelog.BumpMinor},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, git := createTestRepository(t, tc.commits)
+			o := &changelog.Options{
+				Dir:       dir,
+				Git:       git,
+				TagPrefix: tc.tagPrefix,
+			}
+			got, err := o.CalculateNextVersion(tc.prerelease)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, *got)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package changelog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// DefaultIssueCommentTemplate the default template of the comment posted on the issues of a release
+const DefaultIssueCommentTemplate = `Released in version {{ .Version }}{{ if .ReleaseNotesURL }}: {{ .ReleaseNotesURL }}{{ end }}`
+
+// IssueComment the data the issue comment template is executed with
+type IssueComment struct {
+	// Version the version of the release
+	Version string
+
+	// ReleaseNotesURL the URL of the release notes if they were published
+	ReleaseNotesURL string
+
+	// Issue the issue being commented on
+	Issue *v1.IssueSummary
+
+	// Release the release the issue is part of
+	Release *v1.ReleaseSpec
+}
+
+// CommentOnIssues posts a comment rendered from the go template on each issue of the release. Issues which already
+// have the same comment are skipped so that it is safe to run again for the same release. Issue trackers which can
+// not list comments are commented on without checking for an existing comment. Returns the number of issues
+// commented on.
+func CommentOnIssues(tracker issues.IssueProvider, spec *v1.ReleaseSpec, templateText string) (int, error) {
+	if templateText == "" {
+		templateText = DefaultIssueCommentTemplate
+	}
+	tmpl, err := template.New("issueComment").Parse(templateText)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse issue comment template: %w", err)
+	}
+
+	count := 0
+	var failed []string
+	for k := range spec.Issues {
+		issue := &spec.Issues[k]
+		var buffer bytes.Buffer
+		err = tmpl.Execute(&buffer, &IssueComment{
+			Version:         spec.Version,
+			ReleaseNotesURL: spec.ReleaseNotesURL,
+			Issue:           issue,
+			Release:         spec,
+		})
+		if err != nil {
+			return count, fmt.Errorf("failed to render issue comment for issue %s: %w", issue.ID, err)
+		}
+		comment := strings.TrimSpace(buffer.String())
+		if comment == "" {
+			continue
+		}
+
+		existing, err := issueComments(tracker, issue.ID)
+		if err != nil {
+			log.Logger().Warnf("failed to find comments of issue %s: %v", issue.ID, err)
+			failed = append(failed, issue.ID)
+			continue
+		}
+		if hasComment(existing, comment) {
+			log.Logger().Debugf("issue %s already has the release comment", issue.ID)
+			continue
+		}
+		err = tracker.CreateIssueComment(issue.ID, comment)
+		if err != nil {
+			log.Logger().Warnf("failed to comment on issue %s: %v", issue.ID, err)
+			failed = append(failed, issue.ID)
+			continue
+		}
+		count++
+	}
+	if len(failed) > 0 {
+		return count, fmt.Errorf("failed to comment on issues %s", strings.Join(failed, ", "))
+	}
+	return count, nil
+}
+
+// issueComments returns the comments of the issue or none if its issue tracker can not list comments
+func issueComments(tracker issues.IssueProvider, key string) ([]string, error) {
+	lister, ok := tracker.(issues.IssueCommentLister)
+	if !ok {
+		log.Logger().Debugf("not checking issue %s for an existing release comment as its issue tracker does not list comments", key)
+		return nil, nil
+	}
+	comments, err := lister.GetIssueComments(key)
+	if errors.Is(err, issues.ErrCommentsNotSupported) {
+		log.Logger().Debugf("not checking issue %s for an existing release comment as its issue tracker does not list comments", key)
+		return nil, nil
+	}
+	return comments, err
+}
+
+func hasComment(comments []string, comment string) bool {
+	for _, c := range comments {
+		if strings.TrimSpace(c) == comment {
+			return true
+		}
+	}
+	return false
+}
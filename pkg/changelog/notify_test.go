@@ -0,0 +1,73 @@
+//go:build unit
+
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentOnIssues(t *testing.T) {
+	scmClient, fakeData := fake.NewDefault()
+	tracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+	require.NoError(t, err)
+
+	spec := &v1.ReleaseSpec{
+		Version:         "1.2.3",
+		ReleaseNotesURL: "https://github.com/jstrachan/foo/releases/tag/v1.2.3",
+		Issues: []v1.IssueSummary{
+			{ID: "1", Title: "a bug"},
+			{ID: "2", Title: "a feature"},
+		},
+	}
+	count, err := changelog.CommentOnIssues(tracker, spec, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{
+		"jstrachan/foo#1:Released in version 1.2.3: https://github.com/jstrachan/foo/releases/tag/v1.2.3",
+		"jstrachan/foo#2:Released in version 1.2.3: https://github.com/jstrachan/foo/releases/tag/v1.2.3",
+	}, fakeData.IssueCommentsAdded)
+
+	count, err = changelog.CommentOnIssues(tracker, spec, "")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "issues should not be commented on again")
+
+	count, err = changelog.CommentOnIssues(tracker, spec, "Fixed by {{ .Issue.Title }} in {{ .Version }}")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "jstrachan/foo#2:Fixed by a feature in 1.2.3", fakeData.IssueCommentsAdded[3])
+}
+
+// commentOnlyTracker an issue tracker which can not list comments
+type commentOnlyTracker struct {
+	issues.IssueProvider
+}
+
+func TestCommentOnIssuesWithoutListingComments(t *testing.T) {
+	scmClient, fakeData := fake.NewDefault()
+	gitTracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+	require.NoError(t, err)
+	tracker := &commentOnlyTracker{gitTracker}
+
+	spec := &v1.ReleaseSpec{
+		Version: "1.2.3",
+		Issues: []v1.IssueSummary{
+			{ID: "1", Title: "a bug"},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		count, err := changelog.CommentOnIssues(tracker, spec, "")
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, "issues are commented on without checking for existing comments")
+	}
+	assert.Equal(t, []string{
+		"jstrachan/foo#1:Released in version 1.2.3",
+		"jstrachan/foo#1:Released in version 1.2.3",
+	}, fakeData.IssueCommentsAdded)
+}
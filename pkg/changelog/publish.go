@@ -0,0 +1,62 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+)
+
+// FindRelease finds the release for the tag on the git provider. Returns nil if there is no release
+func FindRelease(ctx context.Context, scmClient *scm.Client, fullName, gitKind, tag string) (*scm.Release, error) {
+	rel, _, err := scmClient.Releases.FindByTag(ctx, fullName, tag)
+	if isReleaseNotFound(err, gitKind) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release on repo %s for tag %s: %w", fullName, tag, err)
+	}
+	return rel, nil
+}
+
+// PublishRelease creates the release on the git provider or updates the existing one if it is not nil
+func PublishRelease(ctx context.Context, scmClient *scm.Client, fullName string, existing *scm.Release, releaseInfo *scm.ReleaseInput) (*scm.Release, error) {
+	if existing == nil {
+		rel, _, err := scmClient.Releases.Create(ctx, fullName, releaseInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the release for %s: %w", fullName, err)
+		}
+		return rel, nil
+	}
+	var rel *scm.Release
+	var err error
+	if existing.ID != 0 {
+		rel, _, err = scmClient.Releases.Update(ctx, fullName, existing.ID, releaseInfo)
+	} else {
+		rel, _, err = scmClient.Releases.UpdateByTag(ctx, fullName, existing.Tag, releaseInfo)
+	}
+	if err != nil {
+		id := -1
+		if rel != nil {
+			id = rel.ID
+		}
+		return nil, fmt.Errorf("failed to update the release for %s number: %d: %w", fullName, id, err)
+	}
+	return rel, nil
+}
+
+func isReleaseNotFound(err error, gitKind string) bool {
+	switch gitKind {
+	case "gitlab":
+		// It seems like gitlab is now correctly returning 404 instead of 403, keeping this for now for old on premise gitlab
+		if err != nil {
+			return strings.Contains(err.Error(), "Forbidden") || scmhelpers.IsScmNotFound(err)
+		} else {
+			return false
+		}
+	default:
+		return scmhelpers.IsScmNotFound(err)
+	}
+}
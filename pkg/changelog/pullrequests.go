@@ -0,0 +1,243 @@
+package changelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// pullRequestPageSize the number of pull requests requested per page
+const pullRequestPageSize = 100
+
+// pullRequestSubjectRegex matches the pull request number in the subject of merge commits such as
+// 'Merge pull request #123 from ...' or squashed commits such as 'fix: some fix (#123)'
+var pullRequestSubjectRegex = regexp.MustCompile(`^Merge pull request #(\d+)\b|\(#(\d+)\)$`)
+
+// addPullRequests adds a commit summary for each pull request merged by one of the commits.
+//
+// Pull requests are matched by their merge commit so this works for merge, squash and rebase merges. The other
+// commits of rebase merged pull requests are matched by their message and author. Commits which still don't match
+// are looked up by the pull request number in their subject. The pull request title is used as the message so that
+// commit bodies don't end up in the changelog. Commits which are not part of a merged pull request are left out.
+func (o *Options) addPullRequests(ctx context.Context, spec *v1.ReleaseSpec, commits []object.Commit, since time.Time, resolver *users.GitUserResolver) error {
+	if o.ScmClient == nil || o.ScmClient.PullRequests == nil {
+		return fmt.Errorf("pull request mode requires a git provider client")
+	}
+	remaining := map[string]bool{}
+	order := map[string]int{}
+	for k := range commits {
+		sha := commits[k].Hash.String()
+		remaining[sha] = true
+		order[sha] = k
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	fullName := scm.Join(o.GitURL.Organisation, o.GitURL.Name)
+	opts := &scm.PullRequestListOptions{
+		Size:         pullRequestPageSize,
+		Closed:       true,
+		UpdatedAfter: &since,
+	}
+	var merged []*scm.PullRequest
+	positions := map[int]int{}
+	// the pull requests are not sorted by the time they were merged and go-scm cannot ask for a sort order so
+	// all pages are read until every commit is matched
+	for opts.Page = 1; len(remaining) > 0; opts.Page++ {
+		prs, _, err := o.ScmClient.PullRequests.List(ctx, fullName, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list pull requests of %s: %w", fullName, err)
+		}
+		for _, pr := range prs {
+			if !pr.Merged || !remaining[pr.MergeSha] {
+				continue
+			}
+			delete(remaining, pr.MergeSha)
+			positions[pr.Number] = order[pr.MergeSha]
+			merged = append(merged, pr)
+		}
+		if len(prs) < pullRequestPageSize {
+			break
+		}
+	}
+	o.removeRebasedCommits(ctx, fullName, merged, commits, remaining)
+	merged = append(merged, o.findSubjectPullRequests(ctx, fullName, commits, remaining, positions)...)
+	if len(remaining) > 0 {
+		log.Logger().Infof("ignoring %d commits which are not part of a merged pull request", len(remaining))
+	}
+
+	// keep the order of the commits
+	sort.SliceStable(merged, func(i, j int) bool {
+		return positions[merged[i].Number] < positions[merged[j].Number]
+	})
+	if o.State.MergedPullRequests == nil {
+		o.State.MergedPullRequests = map[string]bool{}
+	}
+	var messages []string
+	var prUsers []scm.User
+	for _, pr := range merged {
+		if o.isIncluded(pr.Title) {
+			o.State.MergedPullRequests[strconv.Itoa(pr.Number)] = false
+			messages = append(messages, pr.Title+"\n\n"+pr.Body)
+			prUsers = append(prUsers, pr.Author)
+			prUsers = append(prUsers, pr.Assignees...)
+		}
+	}
+	o.prefetchUsers(resolver, prUsers)
+	o.prefetchIssues(ctx, messages, resolver)
+	for _, pr := range merged {
+		o.addPullRequest(spec, pr, resolver)
+	}
+	return nil
+}
+
+func (o *Options) addPullRequest(spec *v1.ReleaseSpec, pr *scm.PullRequest, resolver *users.GitUserResolver) {
+	if !o.isIncluded(pr.Title) {
+		return
+	}
+	id := strconv.Itoa(pr.Number)
+	if o.State.MergedPullRequests[id] {
+		log.Logger().Debugf("pull request %s is already in the changelog", id)
+		return
+	}
+	o.State.MergedPullRequests[id] = true
+
+	user, err := resolver.Resolve(&pr.Author)
+	if err != nil {
+		log.Logger().Warnf("Failed to resolve user %v for pull request %s: %v", pr.Author, id, err)
+		o.addFailure(LookupUser, pr.Author.Login, err)
+	}
+	if user == nil {
+		user = resolver.GitUserToUser(&pr.Author)
+	}
+	var assignees []v1.UserDetails
+	if len(pr.Assignees) > 0 {
+		assignees, err = resolver.GitUserSliceAsUserDetailsSlice(pr.Assignees)
+		if err != nil {
+			log.Logger().Warnf("Failed to resolve assignees %v for pull request %s: %v", pr.Assignees, id, err)
+			o.addFailure(LookupUser, logins(pr.Assignees), err)
+		}
+	}
+	var labels []string
+	for _, l := range pr.Labels {
+		if l != nil {
+			labels = append(labels, l.Name)
+		}
+	}
+	state := pr.State
+	if state == "" {
+		state = "merged"
+	}
+
+	commit := v1.CommitSummary{
+		Message:  strings.TrimSpace(pr.Title),
+		URL:      pr.Link,
+		SHA:      pr.MergeSha,
+		Author:   user,
+		Branch:   pr.Base.Ref,
+		IssueIDs: []string{id},
+	}
+	spec.PullRequests = append(spec.PullRequests, v1.IssueSummary{
+		ID:                id,
+		URL:               pr.Link,
+		Title:             pr.Title,
+		Body:              pr.Body,
+		State:             state,
+		User:              user,
+		CreationTimestamp: kube.ToMetaTime(&pr.Created),
+		Assignees:         assignees,
+		Labels:            toV1Labels(labels),
+	})
+
+	o.addIssuesAndPullRequests(spec, &commit, pr.Title+"\n\n"+pr.Body, resolver)
+	spec.Commits = append(spec.Commits, commit)
+}
+
+// removeRebasedCommits removes the commits of the merged pull requests other than their merge commit from the
+// remaining commits. Rebased commits have a new SHA so they are matched by their message and author
+func (o *Options) removeRebasedCommits(ctx context.Context, fullName string, merged []*scm.PullRequest, commits []object.Commit, remaining map[string]bool) {
+	for _, pr := range merged {
+		if len(remaining) == 0 {
+			return
+		}
+		prCommits, _, err := o.ScmClient.PullRequests.ListCommits(ctx, fullName, pr.Number, &scm.ListOptions{Size: pullRequestPageSize})
+		if err != nil {
+			if !errors.Is(err, scm.ErrNotSupported) {
+				log.Logger().Warnf("failed to list the commits of pull request %d: %v", pr.Number, err)
+			}
+			continue
+		}
+		for _, c := range prCommits {
+			for k := range commits {
+				commit := &commits[k]
+				sha := commit.Hash.String()
+				if remaining[sha] && strings.TrimSpace(commit.Message) == strings.TrimSpace(c.Message) && commit.Author.Email == c.Author.Email {
+					delete(remaining, sha)
+				}
+			}
+		}
+	}
+}
+
+// findSubjectPullRequests looks up the merged pull requests whose number is in the subject of the remaining commits
+// such as the pull requests which were not listed by the git provider
+func (o *Options) findSubjectPullRequests(ctx context.Context, fullName string, commits []object.Commit, remaining map[string]bool, positions map[int]int) []*scm.PullRequest {
+	var answer []*scm.PullRequest
+	for k := range commits {
+		sha := commits[k].Hash.String()
+		if !remaining[sha] {
+			continue
+		}
+		subject := strings.TrimSpace(strings.SplitN(commits[k].Message, "\n", 2)[0])
+		m := pullRequestSubjectRegex.FindStringSubmatch(subject)
+		if m == nil {
+			continue
+		}
+		number, err := strconv.Atoi(m[1] + m[2])
+		if err != nil {
+			continue
+		}
+		if _, found := positions[number]; found {
+			delete(remaining, sha)
+			continue
+		}
+		pr, _, err := o.ScmClient.PullRequests.Find(ctx, fullName, number)
+		if err != nil {
+			log.Logger().Debugf("failed to find pull request %d of commit %s: %v", number, sha, err)
+			continue
+		}
+		if pr == nil || !pr.Merged {
+			continue
+		}
+		delete(remaining, sha)
+		positions[number] = k
+		answer = append(answer, pr)
+	}
+	return answer
+}
+
+// commitDate returns the committer date of the revision
+func (o *Options) commitDate(rev string) (time.Time, error) {
+	out, err := o.Git.Command(o.Dir, "show", "-s", "--format=%cI", rev)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to find the date of commit %s: %w", rev, err)
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(out))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse the date of commit %s: %w", rev, err)
+	}
+	return t, nil
+}
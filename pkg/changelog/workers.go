@@ -0,0 +1,31 @@
+package changelog
+
+import "sync"
+
+// forEach calls fn for each index from 0 to n-1 with at most Concurrency calls running at once and returns when
+// they have all finished. Callers write the result of each index into its own slot so that the results are in a
+// deterministic order
+func (o *Options) forEach(n int, fn func(i int)) {
+	limit := o.Concurrency
+	if limit < 1 {
+		limit = 1
+	}
+	if limit == 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
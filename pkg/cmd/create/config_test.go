@@ -0,0 +1,30 @@
+//go:build unit
+
+package create
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfigFlagsTakePrecedence(t *testing.T) {
+	cfg := &config.ChangelogConfig{
+		CommentIssues: true,
+		PullRequests:  true,
+		TemplateFile:  "changelog.tmpl",
+	}
+	cfg.Issues.FixVersion = true
+
+	cmd, o := NewCmdChangelogCreate()
+	require.NoError(t, cmd.Flags().Parse([]string{"--comment-issues=false", "--template-file="}))
+	o.Config = cfg
+	require.NoError(t, o.applyConfig())
+
+	assert.False(t, o.CommentIssues, "--comment-issues=false overrides the configuration")
+	assert.Empty(t, o.TemplateFile, "an empty --template-file overrides the configuration")
+	assert.True(t, o.PullRequests, "the configuration is used for flags which are not specified")
+	assert.True(t, o.FixVersion)
+}
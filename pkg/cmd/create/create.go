@@ -4,20 +4,22 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strings"
 	"text/template"
 	"time"
 
 	"github.com/imdario/mergo"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/config"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/helmhelpers"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
-	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
-	"github.com/jenkins-x-plugins/jx-gitops/pkg/releasereport"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/retry"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/variablefinders"
 	"github.com/jenkins-x/go-scm/scm"
 	jxcore "github.com/jenkins-x/jx-api/v4/pkg/apis/core/v4beta1"
@@ -33,19 +35,13 @@ import (
 	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
-	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
 
 	"github.com/ghodss/yaml"
 
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	jenkinsio "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io"
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
@@ -79,7 +75,9 @@ type Options struct {
 	HeaderFile               string
 	Footer                   string
 	FooterFile               string
+	TemplateFile             string
 	OutputMarkdownFile       string
+	OutputJSONFile           string
 	StatusPath               string
 	ChangelogSeparator       string
 	ChangelogOutputSeparator string
@@ -91,18 +89,45 @@ type Options struct {
 	UpdateRelease            bool
 	NoReleaseInDev           bool
 	IncludeMergeCommits      bool
+	PullRequests             bool
+	CommentIssues            bool
+	FixVersion               bool
+	ReleaseFixVersion        bool
+	IssueComment             string
+	IssueTimeout             time.Duration
+	Concurrency              int
+	CacheDir                 string
+	CacheTTL                 time.Duration
+	RefreshCache             bool
+	FailIfIncomplete         bool
+	MaxRetries               int
+	MaxRetryWait             time.Duration
+	AllowExternalTrackers    bool
+	JiraInsecureSkipVerify   bool
 	FailIfFindCommits        bool
 	Draft                    bool
 	Prerelease               bool
 	State                    State
 	ExcludeRegexp            string
 	CompiledExcludeRegexp    *regexp.Regexp
+	ConfigFile               string
+	Generator                *gits.Generator
+	HiddenTypes              []string
+	Config                   *config.ChangelogConfig
+	ExcludeRegexps           []*regexp.Regexp
+	IncludeRegexps           []*regexp.Regexp
+	GitIssueRegexp           *regexp.Regexp
+	JiraIssueRegexp          *regexp.Regexp
+	BugzillaIssueRegexp      *regexp.Regexp
+	TrelloIssueRegexp        *regexp.Regexp
+	AzureIssueRegexp         *regexp.Regexp
+	LinearIssueRegexp        *regexp.Regexp
+	Cmd                      *cobra.Command
 }
 
 type State struct {
 	Tracker         issues.IssueProvider
 	FoundIssueNames map[string]bool
-	LoggedIssueKind bool
 	Release         *v1.Release
 }
 
@@ -142,6 +167,50 @@ var (
 
 Jira API token is taken from the environment variable JIRA_API_TOKEN. Can be populated using the jx-boot-job-env-vars secret.
 
+For Jira Server and Data Center use a personal access token and set 'auth: bearer' in the 'jira' section of the changelog configuration file. The section can also override the Jira server settings and specify a 'caFile' with additional certificates to trust. Certificate verification can only be disabled with '--jira-insecure-skip-verify':
+
+	jira:
+	  serverUrl: https://jira.example.com
+	  project: ABC
+	  projects: [ABC, DEF]
+	  auth: bearer
+	  caFile: certs/ca.pem
+
+To use Bugzilla specify its server URL in the 'bugzilla' section of the changelog configuration file. Bugs are referenced in commit messages like 'bug 12345' or 'rhbz#12345'. The API key is taken from the environment variable BUGZILLA_API_KEY:
+
+	bugzilla:
+	  serverUrl: https://bugzilla.example.com
+	  product: Example
+	  component: core
+
+To use Trello specify the board in the 'trello' section of the changelog configuration file. Cards are referenced in commit messages like 'trello#AbCd1234' or by their URL and their list is used as their state. The API key and token are taken from the environment variables TRELLO_API_KEY and TRELLO_TOKEN:
+
+	trello:
+	  board: AbCd1234
+	  doneLists: [Done]
+
+To use Azure DevOps Boards specify the organization URL and project in the 'azure' section of the changelog configuration file. Work items are referenced in commit messages like 'AB#123'. The personal access token is taken from the environment variable AZURE_DEVOPS_TOKEN:
+
+	azure:
+	  serverUrl: https://dev.azure.com/myorg
+	  project: myproject
+
+To use Linear specify the keys of your teams in the 'linear' section of the changelog configuration file. Only identifiers of issues of these teams such as 'ENG-123' are looked up. The API key is taken from the environment variable LINEAR_API_KEY:
+
+	linear:
+	  teams: [ENG, DES]
+
+In-house issue trackers can be integrated with an external command which is run for each operation such as getIssue or comment with a JSON request on stdin and writes a JSON response to stdout. The pattern matches the references to its issues and the optional 'issueURL' template creates the links of issues whose URL the command did not return. As the command comes from the repository it is only run if you specify '--allow-external-issue-trackers'. It only gets the environment variables PATH, HOME, TMPDIR, LANG, LC_ALL and TZ together with the ones listed in 'env':
+
+	external:
+	- name: tickets
+	  command: ./hack/tickets-provider
+	  pattern: 'TICKET-\d+'
+	  issueURL: 'https://tickets.example.com/{{ .Key }}'
+	  env: [TICKETS_TOKEN]
+
+Several issue trackers can be configured at once. Each one only looks up the references matching its own pattern and they are combined with the git provider so that references like '#123' are still looked up in the git repository. As the bug and work item numbers of Bugzilla and Azure DevOps look like the git issue numbers, their issues are then added to the release with IDs like 'bug-123' and 'AB#123'.
+
 By default jx commands look for a file '~/.jx/gitAuth.yaml' to find the API tokens for Git servers. You can use 'jx create git token' to create a Git token.
 
 Alternatively if you are running this command inside a CI server you can use environment variables to specify the username and API token.
@@ -158,10 +227,59 @@ e.g. define environment variables GIT_USERNAME and GIT_API_TOKEN
 
 		The changelog is generated by parsing the git commits. It will also detect any text like 'fixes #123' to link to issue fixes. You can also use Conventional Commits notation: https://conventionalcommits.org/ to get a nicer formatted changelog. e.g. using commits like 'fix:(my feature) this my fix' or 'feat:(cheese) something'
 
+		If you squash or rebase merge pull requests the pull request titles are usually a better description of the changes than the commits. Use '--pull-requests' to generate the changelog from the titles, labels and authors of the pull requests merged between the revisions which are found via your git provider.
+
+		Use '--comment-issues' to add a comment like 'Released in version 1.2.3' to each issue of the release on your git provider or in Jira. Running the command again for the same release does not add the comment again.
+
+		When using Jira, '--fix-version' adds the release version to the fix versions of each issue of the release, creating the version in the Jira project if needed. Add '--release-fix-version' to also mark the Jira version as released.
+
 		This command also generates a Release Custom Resource Definition you can include in your helm chart to give metadata about the changelog of the application along with metadata about the release (git tag, url, commits, issues fixed etc). Including this metadata in a helm charts means we can do things like automatically comment on issues when they hit Staging or Production; or give detailed descriptions of what things have changed when using GitOps to update versions in an environment by referencing the fixed issues in the Pull Request.
 
 		You can opt out of the release YAML generation via the '--generate-yaml=false' option
 
+		Repositories can configure the changelog in a '.jx/changelog.yaml' file so that no flags are needed in the pipeline. Any flags you specify take precedence. An example configuration:
+
+			groups:
+			- type: security
+			  title: Security Fixes
+			- type: feat
+			  title: Features
+			  aliases:
+			  - feature
+			- type: fix
+			  title: Fixes
+			  labels:
+			  - kind/bug
+			- type: ui
+			  title: User Interface
+			  labels:
+			  - area/ui
+			- type: deps
+			  title: Dependency Updates
+			  order: 10
+			hiddenTypes:
+			- chore
+			- test
+			skipLabels:
+			- skip-changelog
+			labelPrecedence: type
+			exclude:
+			- "^release "
+			issues:
+			  jiraRegexp: '\b(ABC|DEF)-\d+\b'
+			  resolvedOnly: true
+			  fixVersion: true
+			  releaseFixVersion: true
+			headerFile: docs/changelog-header.md
+
+		Only keys of the Jira project of the issue tracker settings such as ABC-123 are looked up in Jira. To reference several projects list them in 'jira.projects' of the configuration file or specify your own 'issues.jiraRegexp'.
+
+		Jira issues which have a resolution or a done status are closed. They get labels for their issue type, status, resolution, resolution date, components and fix versions such as 'type/Bug', 'resolved/2021-01-04' or 'component/UI' so you can use them to group commits. Use 'resolvedOnly' to leave out issues which are not resolved.
+
+		Commits are also put in a section if their pull request or one of their issues has one of the labels of the section. By default the conventional commit type takes precedence so labels are only used for commits without a known type. Use 'labelPrecedence: label' to let labels win instead. Commits whose pull request or issues have one of the 'skipLabels' are left out of the changelog.
+
+		The layout of the changelog can be replaced completely with a go template passed via '--template-file'. The template is executed on the structured changelog which has the Version, the Groups of commits (each with a Title and Entries) without the hidden types, BreakingChanges, Issues, PullRequests and DependencyUpdates. You can see the full data model by writing it out with '--output-json'.
+
 		To update the release notes on your git provider needs a git API token which is usually provided via the Tekton git authentication mechanism.
 
 		Apart from using your git provider as the issue tracker there is also support for Jira. You then specify issues in commit messages with the issue key that looks like ABC-123. You can configure this in in similar ways as environments, see https://jayex.io/v3/develop/environments/config/. An example configuration:
@@ -182,10 +300,22 @@ e.g. define environment variables GIT_USERNAME and GIT_API_TOKEN
 		# specify the version and a header template
 		jx-changelog create --header-file docs/dev/changelog-header.md --version 1.2.3
 
+		# leave chores and tests out of the release notes
+		jx-changelog create --hide-types chore,test
+
+		# comment on the issues of the release
+		jx-changelog create --comment-issues --issue-comment 'Shipped in {{ .Version }}'
+
+		# generate the changelog from the merged pull requests
+		jx-changelog create --pull-requests
+
+		# render the whole changelog with your own go template
+		jx-changelog create --template-file docs/dev/changelog.tmpl
+
 `)
 
-	GitHubIssueRegex = regexp.MustCompile(`\B#\d+\b`)
-	JIRAIssueRegex   = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-\d+\b`)
+	GitHubIssueRegex = changelog.GitHubIssueRegex
+	JIRAIssueRegex   = changelog.JIRAIssueRegex
 
 	conditionalReleaseYAML = `{{- if and (.Capabilities.APIVersions.Has "jenkins.io/v1/Release") (hasKey .Values.jx "releaseCRD") (.Values.jx.releaseCRD)}}
 %s 
@@ -207,6 +337,7 @@ func NewCmdChangelogCreate() (*cobra.Command, *Options) {
 			helper.CheckErr(err)
 		},
 	}
+	o.Cmd = cmd
 	o.ScmFactory.DiscoverFromGit = true
 
 	cmd.Flags().StringVarP(&o.PreviousRevision, "previous-rev", "p", "", "the revision to start changelog from")
@@ -219,6 +350,7 @@ func NewCmdChangelogCreate() (*cobra.Command, *Options) {
 	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "The version to release. Used to find the git tag to generate the changelog for and as title for the release")
 	cmd.Flags().StringVarP(&o.Build, "build", "", "", "The Build number which is used to update the PipelineActivity. If not specified its defaulted from the '$BUILD_NUMBER' environment variable")
 	cmd.Flags().StringVarP(&o.OutputMarkdownFile, "output-markdown", "", "", "Put the changelog output in this file")
+	cmd.Flags().StringVarP(&o.OutputJSONFile, "output-json", "", "", "Put the changelog as structured JSON in this file")
 	cmd.Flags().StringVarP(&o.StatusPath, "status-path", "", filepath.Join("docs", "releases.yaml"), "The path to the deployment status file used to calculate dependency updates.")
 	cmd.Flags().StringVarP(&o.ChangelogSeparator, "changelog-separator", "", os.Getenv("CHANGELOG_SEPARATOR"), "the separator to use when splitting commit message from changelog in the pull request body. Default to ----- or if set the CHANGELOG_SEPARATOR environment variable")
 	cmd.Flags().StringVarP(&o.ChangelogOutputSeparator, "changelog-output-separator", "", "-----", "the separator to use in changelog between changelogs from pull request bodies.")
@@ -230,6 +362,21 @@ func NewCmdChangelogCreate() (*cobra.Command, *Options) {
 	cmd.Flags().BoolVarP(&o.UpdateRelease, "update-release", "", true, "Should we update the release on the Git repository with the changelog.")
 	cmd.Flags().BoolVarP(&o.NoReleaseInDev, "no-dev-release", "", false, "Disables the generation of Release CRDs in the development namespace to track releases being performed")
 	cmd.Flags().BoolVarP(&o.IncludeMergeCommits, "include-merge-commits", "", false, "Include merge commits when generating the changelog")
+	cmd.Flags().BoolVarP(&o.PullRequests, "pull-requests", "", false, "Generate the changelog from the titles, labels and authors of the pull requests merged between the revisions instead of the commit messages. Useful for squash and rebase merge workflows")
+	cmd.Flags().BoolVarP(&o.CommentIssues, "comment-issues", "", false, "Comment on each issue of the release that it has been released. Issues which already have the comment are skipped")
+	cmd.Flags().StringVarP(&o.IssueComment, "issue-comment", "", "", "The go template of the comment posted with --comment-issues. Can use .Version, .ReleaseNotesURL, .Issue and .Release. Defaults to: "+changelog.DefaultIssueCommentTemplate)
+	cmd.Flags().DurationVarP(&o.IssueTimeout, "issue-timeout", "", 5*time.Minute, "The maximum time of each lookup of the issues referenced by the commits. No limit if 0")
+	cmd.Flags().StringVarP(&o.CacheDir, "cache-dir", "", "", "The directory to cache the issues and users looked up on the git provider and issue trackers in so that later runs such as regenerating the changelogs of old tags do not look them up again. Disabled if empty")
+	cmd.Flags().DurationVarP(&o.CacheTTL, "cache-ttl", "", 24*time.Hour, "The time after which cached issues and users are looked up again. Never if 0")
+	cmd.Flags().BoolVarP(&o.RefreshCache, "refresh-cache", "", false, "Look up all issues and users again and update the cache")
+	cmd.Flags().BoolVarP(&o.FailIfIncomplete, "fail-if-incomplete", "", false, "Fail if any issue, user or release could not be looked up even after retrying instead of generating an incomplete changelog")
+	cmd.Flags().IntVarP(&o.MaxRetries, "max-retries", "", retry.DefaultMaxRetries, "The maximum number of retries of git provider requests which were rate limited or failed with a server error. Disabled if 0")
+	cmd.Flags().DurationVarP(&o.MaxRetryWait, "max-retry-wait", "", retry.DefaultMaxWait, "The maximum time to wait before retrying a git provider request such as until a rate limit is reset")
+	cmd.Flags().IntVarP(&o.Concurrency, "concurrency", "", 4, "The maximum number of issues, pull requests and users looked up at once on the git provider and issue trackers")
+	cmd.Flags().BoolVarP(&o.AllowExternalTrackers, "allow-external-issue-trackers", "", false, "Run the external issue tracker commands of the changelog configuration file. They are ignored otherwise as the repository could run any command with access to the pipeline")
+	cmd.Flags().BoolVarP(&o.JiraInsecureSkipVerify, "jira-insecure-skip-verify", "", false, "Disables the verification of the Jira server certificate")
+	cmd.Flags().BoolVarP(&o.FixVersion, "fix-version", "", false, "Add the release version to the fix versions of each Jira issue of the release. The version is created in Jira if it does not exist")
+	cmd.Flags().BoolVarP(&o.ReleaseFixVersion, "release-fix-version", "", false, "Mark the Jira version of the release as released. Used with --fix-version")
 	cmd.Flags().BoolVarP(&o.FailIfFindCommits, "fail-if-no-commits", "", false, "Do we want to fail the build if we don't find any commits to generate the changelog")
 	cmd.Flags().BoolVarP(&o.Draft, "draft", "", false, "The git provider release is marked as draft")
 	cmd.Flags().BoolVarP(&o.Prerelease, "prerelease", "", false, "The git provider release is marked as a pre-release")
@@ -239,10 +386,13 @@ func NewCmdChangelogCreate() (*cobra.Command, *Options) {
 	}
 	cmd.Flags().StringVarP(&o.ExcludeRegexp, "exclude-regexp", "e", defaultExcludeRegexp, `Regexp for excluding commits. Can be set with environment variable CHANGELOG_EXCLUDE_REGEXP.`)
 
+	cmd.Flags().StringVarP(&o.ConfigFile, "config", "", "", "The changelog configuration file. Defaults to "+config.DefaultFileName+" in the repository directory. Command line flags take precedence over the configuration")
+	cmd.Flags().StringSliceVarP(&o.HiddenTypes, "hide-types", "", nil, "The conventional commit types such as chore or test to leave out of the markdown. The commits are still included in the Release YAML")
 	cmd.Flags().StringVarP(&o.Header, "header", "", "", "The changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
 	cmd.Flags().StringVarP(&o.HeaderFile, "header-file", "", "", "The file name of the changelog header in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
 	cmd.Flags().StringVarP(&o.Footer, "footer", "", "", "The changelog footer in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
 	cmd.Flags().StringVarP(&o.FooterFile, "footer-file", "", "", "The file name of the changelog footer in markdown for the changelog. Can use go template expressions on the ReleaseSpec object: https://golang.org/pkg/text/template/")
+	cmd.Flags().StringVarP(&o.TemplateFile, "template-file", "", "", "The file name of a go template used to render the whole changelog instead of the default layout. The template is executed on the structured changelog also written by --output-json: https://golang.org/pkg/text/template/")
 
 	o.ScmFactory.AddFlags(cmd)
 	o.AddBaseFlags(cmd)
@@ -259,6 +409,9 @@ func (o *Options) Validate() error {
 	if err != nil {
 		return fmt.Errorf("failed to discover git repository: %w", err)
 	}
+	if o.ScmFactory.ScmClient != nil {
+		o.ScmFactory.ScmClient.Client = retry.NewClient(o.ScmFactory.ScmClient.Client, o.MaxRetries, o.MaxRetryWait)
+	}
 
 	o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
 	if err != nil {
@@ -278,9 +431,87 @@ func (o *Options) Validate() error {
 		o.CommandRunner = cmdrunner.QuietCommandRunner
 	}
 
+	o.Config, err = config.LoadChangelogConfig(o.ScmFactory.Dir, o.ConfigFile)
+	if err != nil {
+		return err
+	}
+	err = o.applyConfig()
+	if err != nil {
+		return fmt.Errorf("invalid changelog config: %w", err)
+	}
 	return nil
 }
 
+// applyConfig merges the changelog configuration file with the command line flags. Flags which are specified take
+// precedence over the configuration file
+func (o *Options) applyConfig() error {
+	cfg := o.Config
+	if !o.flagChanged("header") && !o.flagChanged("header-file") && o.Header == "" && o.HeaderFile == "" {
+		o.Header = cfg.Header
+		o.HeaderFile = cfg.HeaderFile
+	}
+	if !o.flagChanged("footer") && !o.flagChanged("footer-file") && o.Footer == "" && o.FooterFile == "" {
+		o.Footer = cfg.Footer
+		o.FooterFile = cfg.FooterFile
+	}
+	if !o.flagChanged("template-file") && o.TemplateFile == "" {
+		o.TemplateFile = cfg.TemplateFile
+	}
+	if !o.flagChanged("pull-requests") {
+		o.PullRequests = o.PullRequests || cfg.PullRequests
+	}
+	if !o.flagChanged("comment-issues") {
+		o.CommentIssues = o.CommentIssues || cfg.CommentIssues
+	}
+	if !o.flagChanged("fix-version") {
+		o.FixVersion = o.FixVersion || cfg.Issues.FixVersion
+	}
+	if !o.flagChanged("release-fix-version") {
+		o.ReleaseFixVersion = o.ReleaseFixVersion || cfg.Issues.ReleaseFixVersion
+	}
+	if !o.flagChanged("issue-comment") && o.IssueComment == "" {
+		o.IssueComment = cfg.IssueComment
+	}
+
+	var err error
+	o.ExcludeRegexps, o.IncludeRegexps, err = cfg.CommitFilters()
+	if err != nil {
+		return err
+	}
+	o.GitIssueRegexp, o.JiraIssueRegexp, err = cfg.IssueRegexps()
+	if err != nil {
+		return err
+	}
+	if o.GitIssueRegexp == nil {
+		o.GitIssueRegexp = GitHubIssueRegex
+	}
+	o.BugzillaIssueRegexp, err = cfg.BugzillaIssueRegexp()
+	if err != nil {
+		return err
+	}
+	o.TrelloIssueRegexp, err = cfg.TrelloIssueRegexp()
+	if err != nil {
+		return err
+	}
+	o.AzureIssueRegexp, err = cfg.AzureIssueRegexp()
+	if err != nil {
+		return err
+	}
+	o.LinearIssueRegexp, err = cfg.LinearIssueRegexp()
+	if err != nil {
+		return err
+	}
+
+	o.Generator = cfg.NewGenerator(o.HiddenTypes...)
+	return nil
+}
+
+// flagChanged returns true if the flag was specified on the command line so that it takes precedence over the
+// changelog configuration file
+func (o *Options) flagChanged(name string) bool {
+	return o.Cmd != nil && o.Cmd.Flags().Changed(name)
+}
+
 func (o *Options) Run() error {
 	err := o.Validate()
 	if err != nil {
@@ -294,81 +525,35 @@ func (o *Options) Run() error {
 	}
 
 	dir := o.ScmFactory.Dir
-
-	previousRev := o.PreviousRevision
-	if previousRev == "" {
-		previousDate := o.PreviousDate
-		if previousDate != "" {
-			previousRev, err = gits.GetRevisionBeforeDateText(o.Git(), dir, previousDate)
-			if err != nil {
-				return fmt.Errorf("failed to find commits before date %s: %w", previousDate, err)
-			}
-		}
-	}
 	ctx := context.Background()
 	fullName := scm.Join(o.ScmFactory.Owner, o.ScmFactory.Repository)
 	scmClient := o.ScmFactory.ScmClient
 
-	if previousRev == "" {
-		tagList, err := gits.NTags(o.Git(), dir, 11, o.TagPrefix)
+	gitInfo := o.ScmFactory.GitURL
+	if gitInfo == nil {
+		gitInfo, err = giturl.ParseGitURL(o.ScmFactory.SourceURL)
 		if err != nil {
-			return fmt.Errorf("getting tags in %s: %w", dir, err)
-		}
-		if o.UpdateRelease && scmClient.Releases != nil {
-			for n := 1; n < len(tagList); n++ {
-				previousTag := tagList[n][1]
-				// We ignore tags without releases so changelogs for failed release builds isn't skipped
-				// TODO: Should we care about the status of the release?
-				_, _, err = scmClient.Releases.FindByTag(ctx, fullName, previousTag)
-				if err != nil {
-					continue
-				}
-				previousRev, _, err = gits.GetCommitForTagSha(o.Git(), dir, tagList[n][0], previousTag)
-				if err != nil {
-					return err
-				}
-				break
-			}
-		}
-		if previousRev == "" {
-			if len(tagList) > 1 {
-				// If no release was found use the first tag before current
-				previousRev, _, err = gits.GetCommitForTagSha(o.Git(), dir, tagList[1][0], tagList[1][1])
-				if err != nil {
-					return err
-				}
-			} else {
-				// let's assume we are the first release
-				previousRev, err = gits.GetFirstCommitSha(o.Git(), dir)
-				if err != nil {
-					return fmt.Errorf("failed to find first commit after we found no previous releaes: %w", err)
-				}
-				if previousRev == "" {
-					log.Logger().Info("no previous commit version found so change diff unavailable")
-					return nil
-				}
-			}
+			return fmt.Errorf("failed to parse git URL %s: %w", o.ScmFactory.SourceURL, err)
 		}
 	}
-	currentRev, tagName, err := gits.GetCommitPointedToByLatestTag(o.Git(), dir, o.TagPrefix)
+
+	tracker, err := o.CreateIssueProvider()
 	if err != nil {
 		return err
 	}
-	if o.CurrentRevision != "" {
-		currentRev = o.CurrentRevision
-	}
-	prefix := "v"
-	if o.TagPrefix != "" {
-		prefix = o.TagPrefix
-	}
-	version := o.Version
-	if version != "" && version != tagName && prefix+version != tagName {
-		log.Logger().Warnf("version %s does not match the latest tag %s. Will tag head of default branch with version", info(version), info(tagName))
-		tagName = version
+	o.State.Tracker = tracker
+
+	co := o.ChangelogOptions(gitInfo, tracker)
+	result, err := co.Generate(ctx)
+	if err != nil {
+		return err
 	}
-	if version == "" {
-		version = tagName
+	if result == nil {
+		return nil
 	}
+	o.State.FoundIssueNames = co.State.FoundIssueNames
+	version := result.Version
+	tagName := result.Tag
 
 	templatesDir := o.TemplatesDir
 	if templatesDir == "" {
@@ -396,55 +581,11 @@ func (o *Options) Run() error {
 		}
 	}
 
-	log.Logger().Infof("Generating change log from git ref %s => %s", info(previousRev), info(currentRev))
-
-	gitDir, gitConfDir, err := gitclient.FindGitConfigDir(dir)
-	if err != nil {
-		return err
-	}
-	if gitDir == "" || gitConfDir == "" {
-		log.Logger().Warnf("No git directory could be found from dir %s", dir)
-		return nil
-	}
-
-	gitInfo := o.ScmFactory.GitURL
-	if gitInfo == nil {
-		gitInfo, err = giturl.ParseGitURL(o.ScmFactory.SourceURL)
-		if err != nil {
-			return fmt.Errorf("failed to parse git URL %s: %w", o.ScmFactory.SourceURL, err)
-		}
-	}
-
-	tracker, err := o.CreateIssueProvider()
-	if err != nil {
-		return err
-	}
-	o.State.Tracker = tracker
-
-	o.State.FoundIssueNames = map[string]bool{}
-
-	commits, err := FetchCommits(gitDir, previousRev, currentRev)
-	if err != nil {
-		if o.FailIfFindCommits {
-			return err
-		}
-		log.Logger().Warnf("failed to find git commits between revision %s and %s due to: %s", previousRev, currentRev, err.Error())
-	} else if log.Logger().Logger.IsLevelEnabled(logrus.DebugLevel) {
-		log.Logger().Debugf("Found commits:")
-		for k := range *commits {
-			commit := (*commits)[k]
-			log.Logger().Debugf("  commit %s", commit.Hash)
-			log.Logger().Debugf("  Author: %s <%s>", commit.Author.Name, commit.Author.Email)
-			log.Logger().Debugf("  Date: %s", commit.Committer.When.Format(time.ANSIC))
-			log.Logger().Debugf("      %s\n\n\n", commit.Message)
-		}
-	}
-
-	version = strings.TrimPrefix(version, prefix)
-	specVersion := version
-	if specVersion == "" {
-		specVersion = SpecVersion
+	spec := result.Spec
+	if spec.Version == "" {
+		spec.Version = SpecVersion
 	}
+	spec.Name = SpecName
 
 	release := &v1.Release{
 		TypeMeta: metav1.TypeMeta{
@@ -459,36 +600,11 @@ func (o *Options) Run() error {
 			// ResourceVersion:   "1",
 			DeletionTimestamp: &metav1.Time{},
 		},
-		Spec: v1.ReleaseSpec{
-			Name:          SpecName,
-			Version:       specVersion,
-			GitOwner:      gitInfo.Organisation,
-			GitRepository: gitInfo.Name,
-			GitHTTPURL:    gitInfo.HttpsURL(),
-			GitCloneURL:   gitInfo.CloneURL,
-			Commits:       []v1.CommitSummary{},
-			Issues:        []v1.IssueSummary{},
-			PullRequests:  []v1.IssueSummary{},
-		},
-	}
-
-	resolver := users.GitUserResolver{
-		GitProvider: scmClient,
-	}
-	if commits != nil {
-		for k := range *commits {
-			c := (*commits)[k]
-			o.addCommit(&release.Spec, &c, &resolver, o.CompiledExcludeRegexp)
-		}
-	}
-
-	release.Spec.DependencyUpdates, err = o.getDependencyUpdates(previousRev)
-	if err != nil {
-		log.Logger().Warnf("failed to get dependency updates: %v", err)
+		Spec: *spec,
 	}
 
 	// let's try to update the release
-	markdown, err := gits.GenerateMarkdown(&release.Spec, gitInfo, o.ChangelogSeparator, o.ChangelogOutputSeparator, o.IncludePRChangelog, o.IncludeMergeCommits)
+	markdown, err := o.generateMarkdown(result.Changelog, &release.Spec, gitInfo)
 	if err != nil {
 		return err
 	}
@@ -504,6 +620,9 @@ func (o *Options) Run() error {
 	markdownOutputted := false
 	log.Logger().Debugf("Generated release notes:\n\n%s\n", markdown)
 
+	// a release which could not be published is reported once the other outputs are written
+	var publishErr error
+
 	if version != "" && o.UpdateRelease {
 		releaseInfo := &scm.ReleaseInput{
 			Title:       version,
@@ -517,49 +636,27 @@ func (o *Options) Run() error {
 		if scmClient.Releases == nil {
 			log.Logger().Warnf("scm provider does not support Releases so cannot find releases")
 		} else {
-			rel, _, err := scmClient.Releases.FindByTag(ctx, fullName, tagName)
-
-			if isReleaseNotFound(err, o.ScmFactory.GitKind) {
-				err = nil
-				rel = nil
-			}
+			rel, err := changelog.FindRelease(ctx, scmClient, fullName, o.ScmFactory.GitKind, tagName)
 			if err != nil {
-				return fmt.Errorf("failed to query release on repo %s for tag %s: %w", fullName, tagName, err)
+				return err
 			}
-
-			if rel == nil {
-				rel, _, err = scmClient.Releases.Create(ctx, fullName, releaseInfo)
-				if err != nil {
-					log.Logger().Warnf("Failed to create the release for %s: %s", fullName, err)
-					return nil
-				}
+			rel, err = changelog.PublishRelease(ctx, scmClient, fullName, rel, releaseInfo)
+			if err != nil {
+				log.Logger().Warnf("%s", err)
+				publishErr = err
 			} else {
-				if rel.ID != 0 {
-					rel, _, err = scmClient.Releases.Update(ctx, fullName, rel.ID, releaseInfo)
-				} else {
-					rel, _, err = scmClient.Releases.UpdateByTag(ctx, fullName, rel.Tag, releaseInfo)
+				url := ""
+				if rel != nil {
+					url = rel.Link
 				}
-				if err != nil {
-					id := -1
-					if rel != nil {
-						id = rel.ID
-					}
-					log.Logger().Warnf("Failed to update the release for %s number: %d: %s", fullName, id, err)
-					return nil
+				if url == "" {
+					url = stringhelpers.UrlJoin(gitInfo.HttpsURL(), "releases/tag", tagName)
 				}
+				release.Spec.ReleaseNotesURL = url
+				log.Logger().Infof("updated the release information at %s", info(url))
+				log.Logger().Debugf("added description: %s", markdown)
+				markdownOutputted = true
 			}
-
-			url := ""
-			if rel != nil {
-				url = rel.Link
-			}
-			if url == "" {
-				url = stringhelpers.UrlJoin(gitInfo.HttpsURL(), "releases/tag", tagName)
-			}
-			release.Spec.ReleaseNotesURL = url
-			log.Logger().Infof("updated the release information at %s", info(url))
-			log.Logger().Debugf("added description: %s", markdown)
-			markdownOutputted = true
 		}
 	}
 
@@ -575,6 +672,30 @@ func (o *Options) Run() error {
 		log.Logger().Infof("\nGenerated Changelog:")
 		log.Logger().Infof("%s\n", markdown)
 	}
+	if o.OutputJSONFile != "" {
+		result.Changelog.ReleaseNotesURL = release.Spec.ReleaseNotesURL
+		data, err := json.MarshalIndent(result.Changelog, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal changelog to JSON: %w", err)
+		}
+		err = os.WriteFile(o.OutputJSONFile, data, files.DefaultFileWritePermissions)
+		if err != nil {
+			return fmt.Errorf("failed to save changelog JSON file %s: %w", o.OutputJSONFile, err)
+		}
+		log.Logger().Infof("generated: %s", info(o.OutputJSONFile))
+	}
+
+	if o.CommentIssues && tracker != nil && len(release.Spec.Issues) > 0 {
+		count, err := changelog.CommentOnIssues(tracker, &release.Spec, o.IssueComment)
+		if err != nil {
+			log.Logger().Warnf("%s", err)
+		}
+		log.Logger().Infof("commented on %d issues", count)
+	}
+
+	if o.FixVersion && tracker != nil && version != "" && len(release.Spec.Issues) > 0 {
+		o.setFixVersion(tracker, version, release.Spec.Issues)
+	}
 
 	o.State.Release = release
 	// now lets marshal the release YAML
@@ -648,7 +769,7 @@ func (o *Options) Run() error {
 	if err != nil {
 		return fmt.Errorf("failed to update PipelineActivity: %w", err)
 	}
-	return nil
+	return publishErr
 }
 
 // FindIssueTracker finds the issue tracker from the settings in current repo as well as sourcerepositories and
@@ -745,168 +866,245 @@ func (o *Options) updatePipelineActivity(fn func(activity *v1.PipelineActivity)
 	return nil
 }
 
-// CreateIssueProvider creates the issue provider
+// CreateIssueProvider creates the issue provider. If issue trackers are configured they are combined with the git
+// provider so that references such as ABC-123 are looked up in Jira and #123 in the git provider
 func (o *Options) CreateIssueProvider() (issues.IssueProvider, error) {
-	issueTracker, _ := FindIssueTracker(o.Git(), o.JXClient, "", o.ScmFactory.Dir, o.ScmFactory.Owner, o.ScmFactory.Repository)
-	if issueTracker != nil && issueTracker.Jira != nil {
-		j := issueTracker.Jira
-		jiraAPIToken := os.Getenv("JIRA_API_TOKEN")
-		if jiraAPIToken != "" {
-			return issues.CreateJiraIssueProvider(j.ServerURL, j.Username, jiraAPIToken, j.Project, true)
+	var trackers []*issues.PatternTracker
+	add := func(tracker issues.IssueProvider, err error) error {
+		if err != nil {
+			return err
 		}
-		log.Logger().Warnf("Environment variable JIRA_API_TOKEN can't be found so connection to JIRA can't be made")
-
+		trackers = append(trackers, &issues.PatternTracker{Tracker: tracker})
+		return nil
 	}
-	log.Logger().Infof("Can't find any issue tracker setting; defaulting to git provider: %s",
-		o.ScmFactory.ScmClient.Driver.String())
-	return issues.CreateGitIssueProvider(o.ScmFactory.ScmClient, o.ScmFactory.Owner, o.ScmFactory.Repository)
-}
 
-func (o *Options) Git() gitclient.Interface {
-	if o.GitClient == nil {
-		o.GitClient = cli.NewCLIClient("", o.CommandRunner)
+	issueTracker, _ := FindIssueTracker(o.Git(), o.JXClient, "", o.ScmFactory.Dir, o.ScmFactory.Owner, o.ScmFactory.Repository)
+	jiraOptions := o.jiraOptions(issueTracker)
+	if jiraOptions != nil {
+		jiraOptions.Token = os.Getenv("JIRA_API_TOKEN")
+		if jiraOptions.Token != "" {
+			err := add(issues.NewJiraIssueProvider(jiraOptions, true))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			log.Logger().Warnf("Environment variable JIRA_API_TOKEN can't be found so connection to JIRA can't be made")
+		}
 	}
-	return o.GitClient
-}
-
-func (o *Options) addCommit(spec *v1.ReleaseSpec, commit *object.Commit, resolver *users.GitUserResolver, excludeRegexp *regexp.Regexp) {
-	if (!o.IncludeMergeCommits && !o.IncludePRChangelog && len(commit.ParentHashes) > 1) ||
-		(excludeRegexp != nil && excludeRegexp.MatchString(commit.Message)) {
-		return
+	if o.Config != nil && o.Config.Azure.ServerURL != "" {
+		a := o.Config.Azure
+		token := os.Getenv("AZURE_DEVOPS_TOKEN")
+		if token != "" {
+			err := add(issues.NewAzureIssueProvider(&issues.AzureOptions{
+				ServerURL:    a.ServerURL,
+				Project:      a.Project,
+				Token:        token,
+				WorkItemType: a.WorkItemType,
+				ClosedStates: a.ClosedStates,
+			}))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			log.Logger().Warnf("Environment variable AZURE_DEVOPS_TOKEN can't be found so connection to Azure DevOps can't be made")
+		}
 	}
-	url := ""
-	branch := "master"
-
-	var author, committer *v1.UserDetails
-	var err error
-	sha := commit.Hash.String()
-	if commit.Author.Email != "" && commit.Author.Name != "" {
-		author, err = resolver.GitSignatureAsUser(&commit.Author)
-		if err != nil {
-			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git author %s: %v", commit.Author, err)
+	if o.Config != nil && len(o.Config.Linear.Teams) > 0 {
+		l := o.Config.Linear
+		apiKey := os.Getenv("LINEAR_API_KEY")
+		if apiKey != "" {
+			err := add(issues.NewLinearIssueProvider(&issues.LinearOptions{
+				ServerURL: l.ServerURL,
+				APIKey:    apiKey,
+				Teams:     l.Teams,
+			}))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			log.Logger().Warnf("Environment variable LINEAR_API_KEY can't be found so connection to Linear can't be made")
 		}
 	}
-	if commit.Committer.Email != "" && commit.Committer.Name != "" {
-		committer, err = resolver.GitSignatureAsUser(&commit.Committer)
+	if o.Config != nil && o.Config.Bugzilla.ServerURL != "" {
+		b := o.Config.Bugzilla
+		apiKey := os.Getenv("BUGZILLA_API_KEY")
+		if apiKey == "" {
+			log.Logger().Warnf("Environment variable BUGZILLA_API_KEY can't be found so using anonymous access to Bugzilla")
+		}
+		err := add(issues.CreateBugzillaIssueProvider(b.ServerURL, apiKey, b.Product, b.Component))
 		if err != nil {
-			log.Logger().Warnf("failed to enrich commit with issues, error getting git signature for git committer %s: %v", commit.Committer, err)
+			return nil, err
+		}
+	}
+	if o.Config != nil && o.Config.Trello.Board != "" {
+		t := o.Config.Trello
+		apiKey := os.Getenv("TRELLO_API_KEY")
+		token := os.Getenv("TRELLO_TOKEN")
+		if apiKey != "" && token != "" {
+			err := add(issues.NewTrelloIssueProvider(&issues.TrelloOptions{
+				ServerURL: t.ServerURL,
+				APIKey:    apiKey,
+				Token:     token,
+				Board:     t.Board,
+				List:      t.List,
+				DoneLists: t.DoneLists,
+			}))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			log.Logger().Warnf("Environment variables TRELLO_API_KEY and TRELLO_TOKEN can't be found so connection to Trello can't be made")
 		}
 	}
-	commitSummary := v1.CommitSummary{
-		Message:   commit.Message,
-		URL:       url,
-		SHA:       sha,
-		Author:    author,
-		Branch:    branch,
-		Committer: committer,
+
+	if o.Config != nil && len(o.Config.External) > 0 && !o.AllowExternalTrackers {
+		log.Logger().Warnf("Ignoring the external issue trackers of the changelog configuration as --allow-external-issue-trackers is not specified")
+	} else if o.Config != nil {
+		externals, err := o.Config.ExternalIssueProviders(o.ScmFactory.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, tracker := range externals {
+			err = add(tracker, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	o.addIssuesAndPullRequests(spec, &commitSummary, commit)
-	if o.IncludeMergeCommits || len(commit.ParentHashes) <= 1 {
-		spec.Commits = append(spec.Commits, commitSummary)
+	if len(trackers) == 0 {
+		log.Logger().Infof("Can't find any issue tracker setting; defaulting to git provider: %s",
+			o.ScmFactory.ScmClient.Driver.String())
+		return issues.CreateGitIssueProvider(o.ScmFactory.ScmClient, o.ScmFactory.Owner, o.ScmFactory.Repository)
+	}
+	gitTracker, err := issues.CreateGitIssueProvider(o.ScmFactory.ScmClient, o.ScmFactory.Owner, o.ScmFactory.Repository)
+	if err != nil {
+		log.Logger().Warnf("Not looking up issues in the git provider: %v", err)
+	} else {
+		trackers = append(trackers, &issues.PatternTracker{Tracker: gitTracker})
+	}
+	if len(trackers) == 1 {
+		return trackers[0].Tracker, nil
 	}
+	return issues.NewCompositeIssueProvider(trackers...), nil
 }
 
-func (o *Options) addIssuesAndPullRequests(spec *v1.ReleaseSpec, commit *v1.CommitSummary, rawCommit *object.Commit) {
-	tracker := o.State.Tracker
-
-	issueKind := issues.GetIssueProvider(tracker)
-	if !o.State.LoggedIssueKind {
-		o.State.LoggedIssueKind = true
-		log.Logger().Infof("Finding issues in commit messages using %s format", issueKind)
+// jiraOptions returns the Jira settings of the issue tracker overridden by the ones in the changelog configuration
+// or nil if Jira is not configured
+func (o *Options) jiraOptions(issueTracker *jxcore.IssueTracker) *issues.JiraOptions {
+	answer := &issues.JiraOptions{}
+	if issueTracker != nil && issueTracker.Jira != nil {
+		j := issueTracker.Jira
+		answer.ServerURL = j.ServerURL
+		answer.Username = j.Username
+		answer.Project = j.Project
+	}
+	if o.Config != nil {
+		j := o.Config.Jira
+		if j.ServerURL != "" {
+			answer.ServerURL = j.ServerURL
+		}
+		if j.Username != "" {
+			answer.Username = j.Username
+		}
+		if j.Project != "" {
+			answer.Project = j.Project
+		}
+		answer.AuthMode = j.Auth
+		answer.CAFile = j.CAFile
+		if j.InsecureSkipVerify && !o.JiraInsecureSkipVerify {
+			log.Logger().Warnf("Ignoring insecureSkipVerify of the Jira configuration as the repository cannot disable certificate verification. Use --jira-insecure-skip-verify instead")
+		}
 	}
-	if issueKind == issues.Jira {
-		o.addIssuesAndPullRequestsWithPattern(spec, commit, JIRAIssueRegex, rawCommit.Message, tracker)
+	answer.InsecureSkipVerify = o.JiraInsecureSkipVerify
+	if answer.ServerURL == "" {
+		return nil
 	}
-
-	o.addIssuesAndPullRequestsWithPattern(spec, commit, GitHubIssueRegex, rawCommit.Message, tracker)
+	return answer
 }
 
-func (o *Options) addIssuesAndPullRequestsWithPattern(spec *v1.ReleaseSpec, commit *v1.CommitSummary, regex *regexp.Regexp, message string, tracker issues.IssueProvider) {
-	matches := regex.FindAllString(message, -1)
-
-	resolver := users.GitUserResolver{
-		GitProvider: o.ScmFactory.ScmClient,
+// ChangelogOptions returns the options to generate the changelog with
+func (o *Options) ChangelogOptions(gitInfo *giturl.GitRepository, tracker issues.IssueProvider) *changelog.Options {
+	var excludeRegexps []*regexp.Regexp
+	if o.CompiledExcludeRegexp != nil {
+		excludeRegexps = append(excludeRegexps, o.CompiledExcludeRegexp)
+	}
+	excludeRegexps = append(excludeRegexps, o.ExcludeRegexps...)
+	return &changelog.Options{
+		Dir:                      o.ScmFactory.Dir,
+		PreviousRevision:         o.PreviousRevision,
+		PreviousDate:             o.PreviousDate,
+		CurrentRevision:          o.CurrentRevision,
+		TagPrefix:                o.TagPrefix,
+		Version:                  o.Version,
+		StatusPath:               o.StatusPath,
+		PullRequestMode:          o.PullRequests,
+		IncludeMergeCommits:      o.IncludeMergeCommits,
+		IncludePRChangelog:       o.IncludePRChangelog,
+		FailIfFindCommits:        o.FailIfFindCommits,
+		IgnoreTagsWithoutRelease: o.UpdateRelease,
+		ExcludeRegexps:           excludeRegexps,
+		IncludeRegexps:           o.IncludeRegexps,
+		GitIssueRegexp:           o.GitIssueRegexp,
+		JiraIssueRegexp:          o.JiraIssueRegexp,
+		BugzillaIssueRegexp:      o.BugzillaIssueRegexp,
+		TrelloIssueRegexp:        o.TrelloIssueRegexp,
+		AzureIssueRegexp:         o.AzureIssueRegexp,
+		LinearIssueRegexp:        o.LinearIssueRegexp,
+		ResolvedIssuesOnly:       o.Config != nil && o.Config.Issues.ResolvedOnly,
+		IssueTimeout:             o.IssueTimeout,
+		Concurrency:              o.Concurrency,
+		Cache:                    cache.New(o.CacheDir, o.CacheTTL, o.RefreshCache),
+		FailIfIncomplete:         o.FailIfIncomplete,
+		GitKind:                  o.ScmFactory.GitKind,
+		Git:                      o.Git(),
+		GitURL:                   gitInfo,
+		ScmClient:                o.ScmFactory.ScmClient,
+		IssueProvider:            tracker,
+		Generator:                o.Generator,
 	}
-	for _, result := range matches {
-		result = strings.TrimPrefix(result, "#")
-		if issueExists, ok := o.State.FoundIssueNames[result]; !ok {
-			o.State.FoundIssueNames[result] = false
-			issue, err := tracker.GetIssue(result)
-			if err != nil {
-				log.Logger().Warnf("Failed to lookup issue %s in issue tracker %s due to %s", result, tracker.HomeURL(), err)
-				continue
-			}
-			if issue == nil {
-				log.Logger().Warnf("Failed to find issue %s for repository %s", result, tracker.HomeURL())
-				continue
-			}
-			o.State.FoundIssueNames[result] = true
-			commit.IssueIDs = append(commit.IssueIDs, result)
-
-			var user *v1.UserDetails
-			if issues.GetIssueProvider(tracker) == issues.Git {
-				user, err = resolver.Resolve(&issue.Author)
-				if err != nil {
-					log.Logger().Warnf("Failed to resolve user %v for issue %s repository %s", issue.Author, result, tracker.HomeURL())
-				}
-			} else {
-				auth := &issue.Author
-				user = &v1.UserDetails{
-					Login:     auth.Login,
-					Name:      auth.Name,
-					Email:     auth.Email,
-					URL:       auth.Link,
-					AvatarURL: auth.Avatar,
-				}
-			}
+}
 
-			var assignees []v1.UserDetails
-			if issue.Assignees == nil {
-				log.Logger().Warnf("Failed to find assignees for issue %s repository %s", result, tracker.HomeURL())
-			} else {
-				u, err := resolver.GitUserSliceAsUserDetailsSlice(issue.Assignees)
-				if err != nil {
-					log.Logger().Warnf("Failed to resolve Assignees %v for issue %s repository %s", issue.Assignees, result, tracker.HomeURL())
-				}
-				assignees = u
-			}
+func (o *Options) Git() gitclient.Interface {
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", o.CommandRunner)
+	}
+	return o.GitClient
+}
 
-			labels := toV1Labels(issue.Labels)
-			issueSummary := v1.IssueSummary{
-				ID:                result,
-				URL:               issue.Link,
-				Title:             issue.Title,
-				Body:              issue.Body,
-				User:              user,
-				CreationTimestamp: kube.ToMetaTime(&issue.Created),
-				Assignees:         assignees,
-				Labels:            labels,
-			}
-			state := issue.State
-			if state != "" {
-				issueSummary.State = state
-			}
-			if issue.PullRequest != nil {
-				spec.PullRequests = append(spec.PullRequests, issueSummary)
-			} else {
-				spec.Issues = append(spec.Issues, issueSummary)
-			}
-		} else if issueExists {
-			commit.IssueIDs = stringhelpers.EnsureStringArrayContains(commit.IssueIDs, result)
-		}
+// setFixVersion adds the version to the fix versions of the issues if the issue tracker supports it
+func (o *Options) setFixVersion(tracker issues.IssueProvider, version string, releaseIssues []v1.IssueSummary) {
+	fvt, ok := tracker.(issues.FixVersionTracker)
+	if !ok {
+		log.Logger().Warnf("the issue tracker %s does not support fix versions", tracker.HomeURL())
+		return
+	}
+	var keys []string
+	for k := range releaseIssues {
+		keys = append(keys, releaseIssues[k].ID)
 	}
+	err := fvt.SetFixVersion(version, keys, o.ReleaseFixVersion)
+	if err != nil {
+		log.Logger().Warnf("%s", err)
+		return
+	}
+	log.Logger().Infof("added fix version %s to %d issues", info(version), len(keys))
 }
 
-// toV1Labels converts git labels to IssueLabel
-func toV1Labels(labels []string) []v1.IssueLabel {
-	var answer []v1.IssueLabel
-	for _, label := range labels {
-		answer = append(answer, v1.IssueLabel{
-			Name: label,
-		})
+// generateMarkdown renders the changelog using the template file if one is specified or the default layout otherwise
+func (o *Options) generateMarkdown(changelog *gits.Changelog, releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository) (string, error) {
+	if o.TemplateFile == "" {
+		return o.Generator.GenerateMarkdown(releaseSpec, gitInfo, o.ChangelogSeparator, o.ChangelogOutputSeparator, o.IncludePRChangelog, o.IncludeMergeCommits)
 	}
-	return answer
+	data, err := os.ReadFile(o.TemplateFile) //nolint:gosec // path is a CLI flag, not user input
+	if err != nil {
+		return "", fmt.Errorf("failed to read template file %s: %w", o.TemplateFile, err)
+	}
+	markdown, err := gits.GenerateMarkdownFromTemplate(changelog, gitInfo, string(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to render template file %s: %w", o.TemplateFile, err)
+	}
+	return markdown, nil
 }
 
 func (o *Options) getTemplateResult(releaseSpec *v1.ReleaseSpec, templateName, templateText, templateFile string) (string, error) {
@@ -936,291 +1134,3 @@ func (o *Options) getTemplateResult(releaseSpec *v1.ReleaseSpec, templateName, t
 	}
 	return buffer.String(), err
 }
-
-func (o *Options) getDependencyUpdates(previousRev string) ([]v1.DependencyUpdate, error) {
-	dir := o.ScmFactory.Dir
-	absStatusPath := filepath.Join(dir, o.StatusPath)
-	releasesExists, err := files.FileExists(absStatusPath)
-	if err != nil {
-		log.Logger().Debugf("fail to check if %s exists", absStatusPath)
-		return nil, nil
-	}
-	if !releasesExists {
-		log.Logger().Debugf("file %s doesn't exists", absStatusPath)
-		return nil, nil
-	}
-	previousReleasesBlob, err := o.Git().Command(dir, "cat-file", "blob", previousRev+":"+o.StatusPath)
-	if err != nil {
-		return nil, fmt.Errorf("fail to check if %s exists for %s: %w", o.StatusPath, previousRev, err)
-	}
-	var previousReleases []*releasereport.NamespaceReleases
-	err = yaml.Unmarshal([]byte(previousReleasesBlob), &previousReleases)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal previous releases %s: %w", previousRev, err)
-	}
-
-	var currentReleases []*releasereport.NamespaceReleases
-	err = yamls.LoadFile(absStatusPath, &currentReleases)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load %s: %w", o.StatusPath, err)
-	}
-
-	previousReleasesMap := makeReleaseMap(&previousReleases)
-	updates := make([]v1.DependencyUpdate, 0)
-
-	for _, nsr := range currentReleases {
-		prevReleases, nsexisted := previousReleasesMap[nsr.Namespace]
-		if !nsexisted {
-			prevReleases = make(map[string]string)
-		}
-		for _, release := range nsr.Releases {
-			prevRel, relexisted := prevReleases[release.ReleaseName]
-			if relexisted {
-				delete(prevReleases, release.ReleaseName)
-			}
-			if prevRel != release.Version {
-				url := release.RepositoryURL
-				if url == "" {
-					url = release.ApplicationURL
-				}
-				updates = append(updates, v1.DependencyUpdate{
-					DependencyUpdateDetails: v1.DependencyUpdateDetails{
-						Component:   release.ReleaseName,
-						URL:         url,
-						FromVersion: prevRel,
-						ToVersion:   release.Version,
-					},
-				})
-			}
-		}
-	}
-
-	for _, nsr := range previousReleasesMap {
-		for name, release := range nsr {
-			updates = append(updates, v1.DependencyUpdate{
-				DependencyUpdateDetails: v1.DependencyUpdateDetails{
-					Component:   name,
-					FromVersion: release,
-				},
-			})
-		}
-	}
-
-	return updates, nil
-}
-
-func makeReleaseMap(namespaceReleases *[]*releasereport.NamespaceReleases) map[string]map[string]string {
-	res := make(map[string]map[string]string)
-	for _, nsr := range *namespaceReleases {
-		res[nsr.Namespace] = make(map[string]string)
-		for _, release := range nsr.Releases {
-			res[nsr.Namespace][release.ReleaseName] = release.Version
-		}
-	}
-	return res
-}
-
-func isReleaseNotFound(err error, gitKind string) bool {
-	switch gitKind {
-	case "gitlab":
-		// It seems like gitlab is now correctly returning 404 instead of 403, keeping this for now for old on premise gitlab
-		if err != nil {
-			return strings.Contains(err.Error(), "Forbidden") || scmhelpers.IsScmNotFound(err)
-		} else {
-			return false
-		}
-	default:
-		return scmhelpers.IsScmNotFound(err)
-	}
-}
-
-// The code below is taken from https://github.com/antham/chyle/blob/master/chyle/git/git.go#L3
-// Unfortunately it can't be imported since it uses an outdated version of go-git.
-
-// node is a tree node in commit tree
-type node struct {
-	value  *object.Commit
-	parent *node
-}
-
-// errNoDiffBetweenReferences is triggered when we can't
-// produce any diff between 2 references
-type errNoDiffBetweenReferences struct {
-	from string
-	to   string
-}
-
-func (e errNoDiffBetweenReferences) Error() string {
-	return fmt.Sprintf(`can't produce a diff between %s and %s, check your range is correct by running "git log %[1]s..%[2]s" command`, e.from, e.to)
-}
-
-// errRepositoryPath is triggered when repository path can't be opened
-type errRepositoryPath struct {
-	path string
-}
-
-func (e errRepositoryPath) Error() string {
-	return fmt.Sprintf(`check %q is an existing git repository path`, e.path)
-}
-
-// errReferenceNotFound is triggered when reference can't be
-// found in git repository
-type errReferenceNotFound struct {
-	ref string
-}
-
-func (e errReferenceNotFound) Error() string {
-	return fmt.Sprintf(`reference %q can't be found in git repository`, e.ref)
-}
-
-// errBrowsingTree is triggered when something wrong occurred during commit analysis process
-var errBrowsingTree = fmt.Errorf("an issue occurred during tree analysis")
-
-// FetchCommits retrieves commits in a reference range
-func FetchCommits(repoPath, fromRef, toRef string) (*[]object.Commit, error) {
-	rep, err := git.PlainOpen(repoPath)
-
-	if err != nil {
-		return nil, errRepositoryPath{repoPath}
-	}
-
-	fromCommit, err := resolveRef(fromRef, rep)
-
-	if err != nil {
-		return &[]object.Commit{}, err
-	}
-
-	toCommit, err := resolveRef(toRef, rep)
-
-	if err != nil {
-		return &[]object.Commit{}, err
-	}
-
-	var ok bool
-	var commits *[]object.Commit
-
-	exclusionList, err := buildOriginCommitList(fromCommit)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if _, ok = exclusionList[toCommit.ID().String()]; ok {
-		return nil, errNoDiffBetweenReferences{fromRef, toRef}
-	}
-
-	commits, err = findDiffCommits(toCommit, exclusionList)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if len(*commits) == 0 {
-		return nil, errNoDiffBetweenReferences{fromRef, toRef}
-	}
-
-	return commits, nil
-}
-
-// resolveRef gives hash commit for a given string reference
-func resolveRef(refCommit string, repository *git.Repository) (*object.Commit, error) {
-	hash := plumbing.Hash{}
-
-	if strings.EqualFold(refCommit, "head") {
-		head, err := repository.Head()
-
-		if err == nil {
-			return repository.CommitObject(head.Hash())
-		}
-	}
-
-	iter, err := repository.References()
-
-	if err != nil {
-		return &object.Commit{}, errReferenceNotFound{refCommit}
-	}
-
-	err = iter.ForEach(func(ref *plumbing.Reference) error {
-		if ref.Name().Short() == refCommit {
-			hash = ref.Hash()
-		}
-
-		return nil
-	})
-
-	if err == nil && !hash.IsZero() {
-		return repository.CommitObject(hash)
-	}
-
-	hash = plumbing.NewHash(refCommit)
-
-	if !hash.IsZero() {
-		return repository.CommitObject(hash)
-	}
-
-	return &object.Commit{}, errReferenceNotFound{refCommit}
-}
-
-// buildOriginCommitList browses git tree from a given commit
-// till root commit using kind of breadth first search algorithm
-// and grab commit ID to a map with ID as key
-func buildOriginCommitList(commit *object.Commit) (map[string]bool, error) {
-	queue := append([]*object.Commit{}, commit)
-	seen := map[string]bool{commit.ID().String(): true}
-
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = append([]*object.Commit{}, queue[1:]...)
-
-		err := current.Parents().ForEach(
-			func(c *object.Commit) error {
-				if _, ok := seen[c.ID().String()]; !ok {
-					seen[c.ID().String()] = true
-					queue = append(queue, c)
-				}
-
-				return nil
-			})
-
-		if err != nil && err.Error() != plumbing.ErrObjectNotFound.Error() {
-			return seen, errBrowsingTree
-		}
-	}
-
-	return seen, nil
-}
-
-// findDiffCommits extracts commits that are no part of a given commit list
-// using kind of depth first search algorithm to keep commits ordered
-func findDiffCommits(commit *object.Commit, exclusionList map[string]bool) (*[]object.Commit, error) {
-	commits := []object.Commit{}
-	queue := append([]*node{}, &node{value: commit})
-	seen := map[string]bool{commit.ID().String(): true}
-	var current *node
-
-	for len(queue) > 0 {
-		current = queue[0]
-		queue = append([]*node{}, queue[1:]...)
-
-		if _, ok := exclusionList[current.value.ID().String()]; !ok {
-			commits = append(commits, *(current.value))
-		}
-
-		err := current.value.Parents().ForEach(
-			func(c *object.Commit) error {
-				if _, ok := seen[c.ID().String()]; !ok {
-					seen[c.ID().String()] = true
-					n := &node{value: c, parent: current}
-					queue = append([]*node{n}, queue...)
-				}
-
-				return nil
-			})
-
-		if err != nil && err.Error() != plumbing.ErrObjectNotFound.Error() {
-			return &commits, errBrowsingTree
-		}
-	}
-
-	return &commits, nil
-}
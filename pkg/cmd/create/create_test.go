@@ -7,13 +7,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/ghodss/yaml"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/releasereport"
 	"github.com/jenkins-x/go-scm/scm"
 	scmfake "github.com/jenkins-x/go-scm/scm/driver/fake"
@@ -190,22 +187,3 @@ func TestCreateDependencyUpdates(t *testing.T) {
 		oldName, "", replaceRel.Version)
 	assert.Contains(t, string(markdown), dependencyUpdates)
 }
-
-func TestAddCommit(t *testing.T) {
-	_, o := NewCmdChangelogCreate()
-
-	release := v1.ReleaseSpec{
-		Name:         SpecName,
-		Commits:      []v1.CommitSummary{},
-		Issues:       []v1.IssueSummary{},
-		PullRequests: []v1.IssueSummary{},
-	}
-	exclude, _ := regexp.Compile(o.ExcludeRegexp)
-	o.addCommit(&release, &object.Commit{Message: "release 1.0.0", Hash: plumbing.NewHash("123")}, nil, exclude)
-	assert.Empty(t, release.Commits)
-	exclude, _ = regexp.Compile(`^chore`)
-	o.addCommit(&release, &object.Commit{Message: "chore: updated dependency", Hash: plumbing.NewHash("234")}, nil, exclude)
-	assert.Empty(t, release.Commits)
-	o.addCommit(&release, &object.Commit{Message: "feat: Cool new feature", Hash: plumbing.NewHash("234")}, nil, exclude)
-	assert.Len(t, release.Commits, 1)
-}
@@ -0,0 +1,265 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/config"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/lint"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	GitClient     gitclient.Interface
+	CommandRunner cmdrunner.CommandRunner
+	Stdin         io.Reader
+
+	Dir              string
+	ConfigFile       string
+	PreviousRevision string
+	CurrentRevision  string
+	TagPrefix        string
+	MessageFile      string
+	Rules            lint.Rules
+	Linter           *lint.Linter
+}
+
+// Commit a commit message to lint
+type Commit struct {
+	SHA     string
+	Message string
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Checks that commit messages are formatted as Conventional Commits so they are grouped correctly in the changelog
+
+		By default the commits since the latest tag are checked. Use '--file' to check a single message such as from a git commit-msg hook.
+
+		Additional rules such as the allowed types and scopes, the maximum header length and a required issue reference can be specified on the command line or in the 'lint' section of the changelog configuration file. Values specified on the command line take precedence.
+
+		The command exits with a non-zero status if any commit message is invalid.
+
+		e.g. in .jx/changelog.yaml:
+
+		  lint:
+		    types: [feat, fix, docs, chore, refactor, test]
+		    scopes: [cli, api]
+		    requireScope: false
+		    maxHeaderLength: 100
+		    requireIssue: true
+`)
+
+	cmdExample = templates.Examples(`
+		# lint the commits since the latest tag
+		jx-changelog lint
+
+		# lint the commits of a pull request
+		jx-changelog lint --previous-rev origin/main
+
+		# lint the message in a commit-msg hook
+		jx-changelog lint --file "$1"
+
+		# lint a message from stdin
+		echo "feat: my feature" | jx-changelog lint --file -
+`)
+)
+
+// NewCmdLint creates the command and options
+func NewCmdLint() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "lint",
+		Short:   "Checks that commit messages are formatted as Conventional Commits",
+		Aliases: []string{"check"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory of the git repository")
+	cmd.Flags().StringVarP(&o.ConfigFile, "config", "", "", "The changelog configuration file. Defaults to "+config.DefaultFileName+" in the repository directory")
+	cmd.Flags().StringVarP(&o.PreviousRevision, "previous-rev", "p", "", "the revision to lint the commits after. Defaults to the latest tag")
+	cmd.Flags().StringVarP(&o.CurrentRevision, "rev", "r", "HEAD", "the revision to lint the commits up to")
+	cmd.Flags().StringVarP(&o.TagPrefix, "tag-prefix", "", "", "prefix to filter on when searching for the latest tag")
+	cmd.Flags().StringVarP(&o.MessageFile, "file", "f", "", "the file containing a single commit message to lint or - for stdin")
+	cmd.Flags().StringSliceVarP(&o.Rules.Types, "types", "", nil, "the allowed commit types")
+	cmd.Flags().StringSliceVarP(&o.Rules.Scopes, "scopes", "", nil, "the allowed commit scopes")
+	cmd.Flags().BoolVarP(&o.Rules.RequireScope, "require-scope", "", false, "requires every commit to have a scope")
+	cmd.Flags().IntVarP(&o.Rules.MaxHeaderLength, "max-header-length", "", 0, "the maximum length of the first line of the message")
+	cmd.Flags().BoolVarP(&o.Rules.RequireIssue, "require-issue", "", false, "requires every commit to reference an issue")
+	o.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate base options: %w", err)
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.QuietCommandRunner
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.Stdin == nil {
+		o.Stdin = os.Stdin
+	}
+	if o.Linter == nil {
+		cfg, err := config.LoadChangelogConfig(o.Dir, o.ConfigFile)
+		if err != nil {
+			return err
+		}
+		gitRegexp, jiraRegexp, err := cfg.IssueRegexps()
+		if err != nil {
+			return err
+		}
+		if gitRegexp == nil {
+			gitRegexp = changelog.GitHubIssueRegex
+		}
+		if jiraRegexp == nil {
+			jiraRegexp = changelog.JIRAIssueRegex
+		}
+		o.Linter = &lint.Linter{
+			Rules:        o.mergeRules(cfg.Lint),
+			Generator:    cfg.NewGenerator(),
+			IssueRegexps: []*regexp.Regexp{gitRegexp, jiraRegexp},
+		}
+	}
+	return nil
+}
+
+// mergeRules returns the rules of the configuration file overridden by the ones specified on the command line
+func (o *Options) mergeRules(rules lint.Rules) lint.Rules {
+	if len(o.Rules.Types) > 0 {
+		rules.Types = o.Rules.Types
+	}
+	if len(o.Rules.Scopes) > 0 {
+		rules.Scopes = o.Rules.Scopes
+	}
+	if o.Rules.RequireScope {
+		rules.RequireScope = true
+	}
+	if o.Rules.MaxHeaderLength > 0 {
+		rules.MaxHeaderLength = o.Rules.MaxHeaderLength
+	}
+	if o.Rules.RequireIssue {
+		rules.RequireIssue = true
+	}
+	return rules
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate: %w", err)
+	}
+	var commits []Commit
+	if o.MessageFile != "" {
+		commit, err := o.readMessageFile()
+		if err != nil {
+			return err
+		}
+		commits = append(commits, commit)
+	} else {
+		commits, err = o.FindCommits()
+		if err != nil {
+			return err
+		}
+	}
+
+	invalid := 0
+	for _, c := range commits {
+		violations := o.Linter.Lint(c.Message)
+		if len(violations) == 0 {
+			continue
+		}
+		invalid++
+		name := "message"
+		if c.SHA != "" {
+			name = "commit " + c.SHA
+		}
+		fmt.Fprintf(o.Out, "%s: %s\n", termcolor.ColorWarning(name), strings.Split(strings.TrimSpace(c.Message), "\n")[0])
+		for _, v := range violations {
+			fmt.Fprintf(o.Out, "  - %s\n", v.String())
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d commit messages are invalid", invalid, len(commits))
+	}
+	log.Logger().Infof("all %d commit messages are valid", len(commits))
+	return nil
+}
+
+func (o *Options) readMessageFile() (Commit, error) {
+	var data []byte
+	var err error
+	if o.MessageFile == "-" {
+		data, err = io.ReadAll(o.Stdin)
+	} else {
+		data, err = os.ReadFile(o.MessageFile)
+	}
+	if err != nil {
+		return Commit{}, fmt.Errorf("failed to read commit message %s: %w", o.MessageFile, err)
+	}
+	return Commit{Message: lint.CleanMessage(string(data))}, nil
+}
+
+// FindCommits returns the non merge commits in the revision range
+func (o *Options) FindCommits() ([]Commit, error) {
+	previousRev := o.PreviousRevision
+	if previousRev == "" {
+		tagList, err := gits.NTags(o.Git(), o.Dir, 1, o.TagPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("getting tags in %s: %w", o.Dir, err)
+		}
+		if len(tagList) > 0 {
+			previousRev = "refs/tags/" + tagList[0][1]
+		}
+	}
+	revRange := o.CurrentRevision
+	if previousRev != "" {
+		revRange = previousRev + ".." + o.CurrentRevision
+	}
+	out, err := o.Git().Command(o.Dir, "log", "--no-merges", "--format=%H%x00%B%x00", revRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find commits in %s: %w", revRange, err)
+	}
+	var answer []Commit
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		answer = append(answer, Commit{
+			SHA:     strings.TrimSpace(fields[i]),
+			Message: strings.TrimSpace(fields[i+1]),
+		})
+	}
+	return answer, nil
+}
+
+func (o *Options) Git() gitclient.Interface {
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", o.CommandRunner)
+	}
+	return o.GitClient
+}
@@ -0,0 +1,149 @@
+package nextversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/changelog"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/config"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// Options contains the command line flags
+type Options struct {
+	options.BaseOptions
+
+	GitClient     gitclient.Interface
+	CommandRunner cmdrunner.CommandRunner
+
+	Dir        string
+	TagPrefix  string
+	Prerelease string
+	ConfigFile string
+	Output     string
+	Result     *changelog.NextVersion
+}
+
+var (
+	cmdLong = templates.LongDesc(`
+		Calculates the next semantic version from the Conventional Commits since the latest version tag
+
+		Breaking changes, marked with '!' after the type or a 'BREAKING CHANGE:' footer, increment the major version, new features ('feat') increment the minor version and any other commits increment the patch version. Commits are parsed the same way as when creating the changelog and the commit groups, aliases, exclude and include rules of the changelog configuration file are used.
+
+		If you specify '--prerelease' the next version is a pre-release such as 1.2.0-rc.1 and the number is incremented for each subsequent pre-release of the same version.
+`)
+
+	cmdExample = templates.Examples(`
+		# print the next version
+		jx-changelog next-version
+
+		# print the major, minor or patch increment
+		jx-changelog next-version --output bump
+
+		# calculate the next release candidate for tags like myapp-1.2.3
+		jx-changelog next-version --tag-prefix myapp- --prerelease rc
+`)
+)
+
+// NewCmdNextVersion creates the command and options
+func NewCmdNextVersion() (*cobra.Command, *Options) {
+	o := &Options{}
+	cmd := &cobra.Command{
+		Use:     "next-version",
+		Short:   "Calculates the next semantic version from the Conventional Commits since the latest tag",
+		Aliases: []string{"nextversion", "next"},
+		Long:    cmdLong,
+		Example: cmdExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory of the git repository")
+	cmd.Flags().StringVarP(&o.TagPrefix, "tag-prefix", "", "", "prefix to filter on when searching for version tags")
+	cmd.Flags().StringVarP(&o.Prerelease, "prerelease", "", "", "the pre-release identifier such as alpha or rc to create a pre-release version")
+	cmd.Flags().StringVarP(&o.ConfigFile, "config", "", "", "The changelog configuration file. Defaults to "+config.DefaultFileName+" in the repository directory")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "version", "what to output. One of: version, tag, bump, json")
+	o.AddBaseFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	err := o.BaseOptions.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate base options: %w", err)
+	}
+	switch o.Output {
+	case "version", "tag", "bump", "json":
+	default:
+		return fmt.Errorf("invalid --output %s should be one of: version, tag, bump, json", o.Output)
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.QuietCommandRunner
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate: %w", err)
+	}
+	cfg, err := config.LoadChangelogConfig(o.Dir, o.ConfigFile)
+	if err != nil {
+		return err
+	}
+	exclude, include, err := cfg.CommitFilters()
+	if err != nil {
+		return fmt.Errorf("invalid changelog config: %w", err)
+	}
+	co := &changelog.Options{
+		Dir:            o.Dir,
+		TagPrefix:      o.TagPrefix,
+		ExcludeRegexps: exclude,
+		IncludeRegexps: include,
+		Git:            o.Git(),
+		Generator:      cfg.NewGenerator(),
+	}
+	o.Result, err = co.CalculateNextVersion(o.Prerelease)
+	if err != nil {
+		return fmt.Errorf("failed to calculate the next version: %w", err)
+	}
+	log.Logger().Debugf("previous version %s with a %s increment", o.Result.PreviousVersion, o.Result.Bump)
+
+	switch o.Output {
+	case "tag":
+		_, err = fmt.Fprintln(o.Out, o.Result.Tag)
+	case "bump":
+		_, err = fmt.Fprintln(o.Out, o.Result.Bump)
+	case "json":
+		var data []byte
+		data, err = json.MarshalIndent(o.Result, "", "  ")
+		if err == nil {
+			_, err = fmt.Fprintln(o.Out, string(data))
+		}
+	default:
+		_, err = fmt.Fprintln(o.Out, o.Result.Version)
+	}
+	return err
+}
+
+func (o *Options) Git() gitclient.Interface {
+	if o.GitClient == nil {
+		o.GitClient = cli.NewCLIClient("", o.CommandRunner)
+	}
+	return o.GitClient
+}
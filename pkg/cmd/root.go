@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/create"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/lint"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cmd/nextversion"
 	"github.com/jenkins-x-plugins/jx-changelog/pkg/common"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
@@ -33,6 +35,8 @@ func Main() *cobra.Command {
 	o := options.BaseOptions{}
 	o.AddBaseFlags(cmd)
 	cmd.AddCommand(cobras.SplitCommand(create.NewCmdChangelogCreate()))
+	cmd.AddCommand(cobras.SplitCommand(lint.NewCmdLint()))
+	cmd.AddCommand(cobras.SplitCommand(nextversion.NewCmdNextVersion()))
 	cmd.AddCommand(cobras.SplitCommand(version.NewCmdVersion()))
 	return cmd
 }
@@ -0,0 +1,411 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/lint"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+)
+
+const (
+	// DefaultFileName the default name of the changelog configuration file relative to the repository directory
+	DefaultFileName = ".jx/changelog.yaml"
+)
+
+// ChangelogConfig the repository level configuration of the changelog.
+// Values specified on the command line take precedence over the ones in the file.
+type ChangelogConfig struct {
+	// Groups the titles and order of the conventional commit types
+	Groups []gits.CommitGroupConfig `json:"groups,omitempty"`
+
+	// HiddenTypes the conventional commit types which are left out of the generated markdown
+	HiddenTypes []string `json:"hiddenTypes,omitempty"`
+
+	// SkipLabels the pull request or issue labels which leave commits out of the changelog. Defaults to
+	// skip-changelog
+	SkipLabels []string `json:"skipLabels,omitempty"`
+
+	// LabelPrecedence whether the conventional commit type or a label decides the section of a commit when they
+	// disagree. One of type or label. Defaults to type
+	LabelPrecedence string `json:"labelPrecedence,omitempty"`
+
+	// Exclude regular expressions for commit messages to exclude from the changelog
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Include regular expressions for commit messages to include in the changelog. If specified only matching
+	// commits are included
+	Include []string `json:"include,omitempty"`
+
+	// PullRequests generates the changelog from the merged pull requests instead of the commit messages
+	PullRequests bool `json:"pullRequests,omitempty"`
+
+	// CommentIssues comments on each issue of the release that it has been released
+	CommentIssues bool `json:"commentIssues,omitempty"`
+
+	// IssueComment the go template of the comment posted on the issues of the release
+	IssueComment string `json:"issueComment,omitempty"`
+
+	// Issues configures how issues are detected in commit messages
+	Issues IssuesConfig `json:"issues,omitempty"`
+
+	// Jira the settings to connect to Jira which take precedence over the issue tracker settings of the cluster
+	Jira JiraConfig `json:"jira,omitempty"`
+
+	// Bugzilla the settings to connect to Bugzilla. If a server URL is specified Bugzilla is used as issue tracker
+	Bugzilla BugzillaConfig `json:"bugzilla,omitempty"`
+
+	// Trello the settings to connect to Trello. If a board is specified Trello is used as issue tracker
+	Trello TrelloConfig `json:"trello,omitempty"`
+
+	// Azure the settings to connect to Azure DevOps Boards. If an organization URL is specified Azure DevOps work
+	// items are used as issues
+	Azure AzureConfig `json:"azure,omitempty"`
+
+	// Linear the settings to connect to Linear. If teams are specified Linear is used as issue tracker
+	Linear LinearConfig `json:"linear,omitempty"`
+
+	// External the external commands implementing issue trackers such as in-house ones
+	External []ExternalConfig `json:"external,omitempty"`
+
+	// Header the changelog header template
+	Header string `json:"header,omitempty"`
+
+	// HeaderFile the file containing the changelog header template
+	HeaderFile string `json:"headerFile,omitempty"`
+
+	// Footer the changelog footer template
+	Footer string `json:"footer,omitempty"`
+
+	// FooterFile the file containing the changelog footer template
+	FooterFile string `json:"footerFile,omitempty"`
+
+	// TemplateFile the file containing the template for the whole changelog
+	TemplateFile string `json:"templateFile,omitempty"`
+
+	// Lint the rules commit messages are checked against by the lint command
+	Lint lint.Rules `json:"lint,omitempty"`
+}
+
+// IssuesConfig configures how issues are detected in commit messages
+type IssuesConfig struct {
+	// GitRegexp the regular expression to find git provider issues such as #123
+	GitRegexp string `json:"gitRegexp,omitempty"`
+
+	// JiraRegexp the regular expression to find Jira issues such as ABC-123
+	JiraRegexp string `json:"jiraRegexp,omitempty"`
+
+	// BugzillaRegexp the regular expression to find Bugzilla bugs such as bug 12345. The first group is the bug id
+	BugzillaRegexp string `json:"bugzillaRegexp,omitempty"`
+
+	// TrelloRegexp the regular expression to find Trello cards such as trello#AbCd1234. The first group is the short
+	// link or ID of the card
+	TrelloRegexp string `json:"trelloRegexp,omitempty"`
+
+	// AzureRegexp the regular expression to find Azure DevOps work items such as AB#123. The first group is the work
+	// item ID
+	AzureRegexp string `json:"azureRegexp,omitempty"`
+
+	// LinearRegexp the regular expression to find Linear issues. Defaults to the identifiers of the issues of the
+	// configured Linear teams such as ENG-123
+	LinearRegexp string `json:"linearRegexp,omitempty"`
+
+	// ResolvedOnly leaves out issues which are not closed or resolved
+	ResolvedOnly bool `json:"resolvedOnly,omitempty"`
+
+	// FixVersion adds the release version to the fix versions of the Jira issues of the release
+	FixVersion bool `json:"fixVersion,omitempty"`
+
+	// ReleaseFixVersion marks the Jira version of the release as released
+	ReleaseFixVersion bool `json:"releaseFixVersion,omitempty"`
+}
+
+// JiraConfig the settings to connect to Jira. The token is taken from the JIRA_API_TOKEN environment variable
+type JiraConfig struct {
+	// ServerURL the URL of the Jira server
+	ServerURL string `json:"serverUrl,omitempty"`
+
+	// Username the user name used for basic authentication
+	Username string `json:"userName,omitempty"`
+
+	// Project the key of the Jira project
+	Project string `json:"project,omitempty"`
+
+	// Projects the keys of the Jira projects whose issues are referenced in commit messages. Only keys of these
+	// projects are looked up so that text like UTF-8 or SHA-256 is not mistaken for an issue. Defaults to the Project
+	Projects []string `json:"projects,omitempty"`
+
+	// Auth how to authenticate with the token. One of basic for Jira Cloud API tokens or bearer for Jira Server and
+	// Data Center personal access tokens. Defaults to basic
+	Auth string `json:"auth,omitempty"`
+
+	// CAFile the file containing PEM encoded certificates to trust in addition to the system ones
+	CAFile string `json:"caFile,omitempty"`
+
+	// InsecureSkipVerify is ignored as the repository should not be able to disable the verification of the Jira
+	// server certificate.
+	//
+	// Deprecated: use the --jira-insecure-skip-verify flag
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// BugzillaConfig the settings to connect to Bugzilla. The API key is taken from the BUGZILLA_API_KEY environment
+// variable
+type BugzillaConfig struct {
+	// ServerURL the URL of the Bugzilla server
+	ServerURL string `json:"serverUrl,omitempty"`
+
+	// Product the product whose bugs are searched and created
+	Product string `json:"product,omitempty"`
+
+	// Component the component of the product new bugs are created in
+	Component string `json:"component,omitempty"`
+}
+
+// TrelloConfig the settings to connect to Trello. The API key and token are taken from the TRELLO_API_KEY and
+// TRELLO_TOKEN environment variables
+type TrelloConfig struct {
+	// ServerURL the URL of the Trello REST API. Defaults to https://api.trello.com
+	ServerURL string `json:"serverUrl,omitempty"`
+
+	// Board the short link or ID of the board
+	Board string `json:"board,omitempty"`
+
+	// List the ID of the list new cards are created in
+	List string `json:"list,omitempty"`
+
+	// DoneLists the names of the lists whose cards are treated as closed such as Done
+	DoneLists []string `json:"doneLists,omitempty"`
+}
+
+// AzureConfig the settings to connect to Azure DevOps Boards. The personal access token is taken from the
+// AZURE_DEVOPS_TOKEN environment variable
+type AzureConfig struct {
+	// ServerURL the URL of the organization such as https://dev.azure.com/myorg
+	ServerURL string `json:"serverUrl,omitempty"`
+
+	// Project the name of the project
+	Project string `json:"project,omitempty"`
+
+	// WorkItemType the type of work items created. Defaults to Bug
+	WorkItemType string `json:"workItemType,omitempty"`
+
+	// ClosedStates the states of work items which are treated as closed. Defaults to Closed, Done, Removed and
+	// Resolved
+	ClosedStates []string `json:"closedStates,omitempty"`
+}
+
+// LinearConfig the settings to connect to Linear. The API key is taken from the LINEAR_API_KEY environment variable
+type LinearConfig struct {
+	// ServerURL the URL of the Linear GraphQL API. Defaults to https://api.linear.app/graphql
+	ServerURL string `json:"serverUrl,omitempty"`
+
+	// Teams the keys of the teams whose issues are referenced in commit messages such as ENG for ENG-123. New
+	// issues are created in the first team
+	Teams []string `json:"teams,omitempty"`
+}
+
+// ExternalConfig an external command implementing an issue tracker. The command is run for each operation with a
+// JSON request on stdin and writes a JSON response to stdout, see issues.ExternalService
+type ExternalConfig struct {
+	// Name the name of the issue tracker used in messages. Defaults to the command
+	Name string `json:"name,omitempty"`
+
+	// Command the command which is looked up on the PATH or relative to the directory of the repository
+	Command string `json:"command"`
+
+	// Args the arguments of the command
+	Args []string `json:"args,omitempty"`
+
+	// Env the names of the environment variables such as the token of the issue tracker which are passed to the
+	// command. Other variables except for PATH, HOME, TMPDIR, LANG, LC_ALL and TZ are not passed
+	Env []string `json:"env,omitempty"`
+
+	// Pattern the regular expression of the references to issues in commit messages such as TICKET-\d+. If it has
+	// a group the first group is the issue key
+	Pattern string `json:"pattern"`
+	// IssueURL the go template of the URL of an issue such as https://tickets.example.com/{{ .Key }} which is used
+	// for the issues whose URL was not returned by the command
+	IssueURL string `json:"issueURL,omitempty"`
+}
+
+// LoadChangelogConfig loads the configuration file. If fileName is empty the default file in the given directory is
+// used. Relative file names inside the configuration are resolved against the directory. An empty configuration is
+// returned if the file does not exist.
+func LoadChangelogConfig(dir, fileName string) (*ChangelogConfig, error) {
+	if fileName == "" {
+		fileName = filepath.Join(dir, DefaultFileName)
+	}
+	config := &ChangelogConfig{}
+	err := yamls.LoadFile(fileName, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load changelog config: %w", err)
+	}
+	switch config.LabelPrecedence {
+	case "", gits.PrecedenceType, gits.PrecedenceLabel:
+	default:
+		return nil, fmt.Errorf("invalid labelPrecedence %s in %s should be %s or %s", config.LabelPrecedence, fileName, gits.PrecedenceType, gits.PrecedenceLabel)
+	}
+	config.HeaderFile = resolvePath(dir, config.HeaderFile)
+	config.Jira.CAFile = resolvePath(dir, config.Jira.CAFile)
+	config.FooterFile = resolvePath(dir, config.FooterFile)
+	config.TemplateFile = resolvePath(dir, config.TemplateFile)
+	for k := range config.External {
+		e := &config.External[k]
+		if e.Command == "" {
+			return nil, fmt.Errorf("no command for external issue tracker %s in %s", e.Name, fileName)
+		}
+		// commands without a path are looked up on the PATH
+		if filepath.Base(e.Command) != e.Command {
+			e.Command = resolvePath(dir, e.Command)
+		}
+	}
+	return config, nil
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// NewGenerator creates a changelog generator with the configured commit groups. The given hidden types are hidden
+// in addition to the configured ones.
+func (c *ChangelogConfig) NewGenerator(hiddenTypes ...string) *gits.Generator {
+	var allHiddenTypes []string
+	allHiddenTypes = append(allHiddenTypes, c.HiddenTypes...)
+	allHiddenTypes = append(allHiddenTypes, hiddenTypes...)
+	g := gits.NewGenerator()
+	if len(c.Groups) > 0 || len(allHiddenTypes) > 0 {
+		g.ConfigureCommitGroups(c.Groups, allHiddenTypes)
+	}
+	if len(c.SkipLabels) > 0 || c.LabelPrecedence != "" {
+		g.ConfigureLabels(c.SkipLabels, c.LabelPrecedence)
+	}
+	return g
+}
+
+// CommitFilters compiles the exclude and include regular expressions
+func (c *ChangelogConfig) CommitFilters() (exclude, include []*regexp.Regexp, err error) {
+	exclude, err = CompileRegexps(c.Exclude)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid exclude: %w", err)
+	}
+	include, err = CompileRegexps(c.Include)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid include: %w", err)
+	}
+	return exclude, include, nil
+}
+
+// IssueRegexps compiles the configured issue regular expressions. If no Jira regular expression is configured it
+// matches the keys of the configured Jira projects. Nil is returned for the ones which are not configured so that
+// the defaults are used.
+func (c *ChangelogConfig) IssueRegexps() (git, jira *regexp.Regexp, err error) {
+	if c.Issues.GitRegexp != "" {
+		git, err = regexp.Compile(c.Issues.GitRegexp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid issues.gitRegexp: %w", err)
+		}
+	}
+	if c.Issues.JiraRegexp != "" {
+		jira, err = regexp.Compile(c.Issues.JiraRegexp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid issues.jiraRegexp: %w", err)
+		}
+	} else {
+		jira = issues.JiraIssueKeyRegexp(c.JiraProjects()...)
+	}
+	return git, jira, nil
+}
+
+// BugzillaIssueRegexp compiles the configured Bugzilla regular expression or returns nil if it is not configured so
+// that the default is used
+func (c *ChangelogConfig) BugzillaIssueRegexp() (*regexp.Regexp, error) {
+	return compileOptionalRegexp(c.Issues.BugzillaRegexp, "issues.bugzillaRegexp")
+}
+
+// TrelloIssueRegexp compiles the configured Trello regular expression or returns nil if it is not configured so
+// that the default is used
+func (c *ChangelogConfig) TrelloIssueRegexp() (*regexp.Regexp, error) {
+	return compileOptionalRegexp(c.Issues.TrelloRegexp, "issues.trelloRegexp")
+}
+
+// AzureIssueRegexp compiles the configured Azure DevOps regular expression or returns nil if it is not configured
+// so that the default is used
+func (c *ChangelogConfig) AzureIssueRegexp() (*regexp.Regexp, error) {
+	return compileOptionalRegexp(c.Issues.AzureRegexp, "issues.azureRegexp")
+}
+
+// LinearIssueRegexp compiles the configured Linear regular expression. If it is not configured it matches the
+// identifiers of the issues of the configured teams or returns nil if there are no teams
+func (c *ChangelogConfig) LinearIssueRegexp() (*regexp.Regexp, error) {
+	if c.Issues.LinearRegexp == "" {
+		return issues.LinearIssueKeyRegexp(c.Linear.Teams...), nil
+	}
+	return compileOptionalRegexp(c.Issues.LinearRegexp, "issues.linearRegexp")
+}
+
+func compileOptionalRegexp(expr, name string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	answer, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return answer, nil
+}
+
+// ExternalIssueProviders creates the issue providers of the external commands which are run in the directory
+func (c *ChangelogConfig) ExternalIssueProviders(dir string) ([]issues.IssueProvider, error) {
+	var answer []issues.IssueProvider
+	for k := range c.External {
+		e := &c.External[k]
+		name := e.Name
+		if name == "" {
+			name = e.Command
+		}
+		if e.Pattern == "" {
+			return nil, fmt.Errorf("no pattern for external issue tracker %s", name)
+		}
+		regex, err := regexp.Compile(e.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of external issue tracker %s: %w", name, err)
+		}
+		tracker, err := issues.NewExternalIssueProvider(name, dir, e.Command, e.IssueURL, e.Args, e.Env, regex)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, tracker)
+	}
+	return answer, nil
+}
+
+// JiraProjects returns the keys of the configured Jira projects
+func (c *ChangelogConfig) JiraProjects() []string {
+	if len(c.Jira.Projects) > 0 {
+		return c.Jira.Projects
+	}
+	if c.Jira.Project != "" {
+		return []string{c.Jira.Project}
+	}
+	return nil
+}
+
+// CompileRegexps compiles the regular expressions
+func CompileRegexps(exprs []string) ([]*regexp.Regexp, error) {
+	var answer []*regexp.Regexp
+	for _, expr := range exprs {
+		r, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, r)
+	}
+	return answer, nil
+}
@@ -0,0 +1,76 @@
+//go:build unit
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/config"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadChangelogConfig(t *testing.T) {
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, ".jx"), 0o755)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, config.DefaultFileName), []byte(`groups:
+- type: feat
+  title: Features
+- type: deps
+  title: Dependencies
+hiddenTypes:
+- chore
+exclude:
+- "^release "
+include:
+- "^(feat|fix)"
+issues:
+  jiraRegexp: '\bABC-\d+\b'
+headerFile: docs/header.md
+footer: "Thanks!"
+`), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := config.LoadChangelogConfig(dir, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []gits.CommitGroupConfig{{Type: "feat", Title: "Features"}, {Type: "deps", Title: "Dependencies"}}, cfg.Groups)
+	assert.Equal(t, []string{"chore"}, cfg.HiddenTypes)
+	assert.Equal(t, []string{"^release "}, cfg.Exclude)
+	assert.Equal(t, []string{"^(feat|fix)"}, cfg.Include)
+	assert.Equal(t, `\bABC-\d+\b`, cfg.Issues.JiraRegexp)
+	assert.Equal(t, filepath.Join(dir, "docs", "header.md"), cfg.HeaderFile)
+	assert.Equal(t, "Thanks!", cfg.Footer)
+}
+
+func TestLoadChangelogConfigMissingFile(t *testing.T) {
+	cfg, err := config.LoadChangelogConfig(t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Equal(t, &config.ChangelogConfig{}, cfg)
+}
+
+func TestLoadChangelogConfigInvalidLabelPrecedence(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "changelog.yaml")
+	err := os.WriteFile(fileName, []byte("labelPrecedence: labels\n"), 0o600)
+	require.NoError(t, err)
+
+	_, err = config.LoadChangelogConfig(filepath.Dir(fileName), fileName)
+	require.Error(t, err)
+}
+
+func TestIssueRegexpsJiraProjects(t *testing.T) {
+	cfg := &config.ChangelogConfig{Jira: config.JiraConfig{Project: "ABC", Projects: []string{"ABC", "DEF"}}}
+	_, jira, err := cfg.IssueRegexps()
+	require.NoError(t, err)
+	require.NotNil(t, jira)
+	assert.Equal(t, []string{"ABC-1", "DEF-2"}, jira.FindAllString("ABC-1 DEF-2 UTF-8", -1))
+
+	cfg.Issues.JiraRegexp = `\bUTF-\d+\b`
+	_, jira, err = cfg.IssueRegexps()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"UTF-8"}, jira.FindAllString("ABC-1 DEF-2 UTF-8", -1), "the configured regexp should take precedence")
+}
@@ -0,0 +1,127 @@
+package gits
+
+import (
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+// Changelog is the structured form of a generated changelog
+type Changelog struct {
+	Version           string                `json:"version,omitempty"`
+	Tag               string                `json:"tag,omitempty"`
+	PreviousRevision  string                `json:"previousRevision,omitempty"`
+	CurrentRevision   string                `json:"currentRevision,omitempty"`
+	ReleaseNotesURL   string                `json:"releaseNotesURL,omitempty"`
+	Groups            []*ChangelogGroup     `json:"groups,omitempty"`
+	BreakingChanges   []*ChangelogEntry     `json:"breakingChanges,omitempty"`
+	Issues            []v1.IssueSummary     `json:"issues,omitempty"`
+	PullRequests      []v1.IssueSummary     `json:"pullRequests,omitempty"`
+	DependencyUpdates []v1.DependencyUpdate `json:"dependencyUpdates,omitempty"`
+}
+
+// ChangelogGroup is a section of the changelog containing the entries of one commit type
+type ChangelogGroup struct {
+	Type    string            `json:"type"`
+	Title   string            `json:"title"`
+	Order   int               `json:"order"`
+	Hidden  bool              `json:"hidden,omitempty"`
+	Entries []*ChangelogEntry `json:"entries"`
+}
+
+// ChangelogEntry is a single parsed commit in the changelog
+type ChangelogEntry struct {
+	SHA         string          `json:"sha,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Scope       string          `json:"scope,omitempty"`
+	Description string          `json:"description"`
+	Breaking    bool            `json:"breaking,omitempty"`
+	Message     string          `json:"message,omitempty"`
+	Author      *v1.UserDetails `json:"author,omitempty"`
+	IssueIDs    []string        `json:"issueIds,omitempty"`
+	Labels      []string        `json:"labels,omitempty"`
+
+	// PullRequest the pull request the commit was merged by if known
+	PullRequest *v1.IssueSummary `json:"pullRequest,omitempty"`
+
+	commit *v1.CommitSummary
+	info   *CommitInfo
+}
+
+// NewChangelog parses the commits of the release and groups them by the default conventional commit groups
+func NewChangelog(releaseSpec *v1.ReleaseSpec) *Changelog {
+	return NewGenerator().NewChangelog(releaseSpec)
+}
+
+func (c *Changelog) addEntry(group *ChangelogGroup, cs *v1.CommitSummary, ci *CommitInfo, labels []string) {
+	user := cs.Author
+	if user == nil {
+		user = cs.Committer
+	}
+	entry := &ChangelogEntry{
+		SHA:         cs.SHA,
+		Type:        ci.Type,
+		Scope:       ci.Scope,
+		Description: firstLine(ci.Description),
+		Breaking:    ci.Type == "break",
+		Message:     cs.Message,
+		Author:      user,
+		IssueIDs:    cs.IssueIDs,
+		Labels:      labels,
+		commit:      cs,
+		info:        ci,
+	}
+	entry.PullRequest = c.pullRequest(cs.IssueIDs)
+	group.Entries = append(group.Entries, entry)
+	if entry.Breaking {
+		c.BreakingChanges = append(c.BreakingChanges, entry)
+	}
+}
+
+// pullRequest returns the first pull request with one of the IDs
+func (c *Changelog) pullRequest(ids []string) *v1.IssueSummary {
+	for _, id := range ids {
+		for i := range c.PullRequests {
+			if c.PullRequests[i].ID == id {
+				return &c.PullRequests[i]
+			}
+		}
+	}
+	return nil
+}
+
+// labels returns the labels of the pull requests and issues with the IDs. Pull request labels come first
+func (c *Changelog) labels(ids []string) []string {
+	var answer []string
+	found := map[string]bool{}
+	for _, issues := range [][]v1.IssueSummary{c.PullRequests, c.Issues} {
+		for _, id := range ids {
+			for i := range issues {
+				if issues[i].ID != id {
+					continue
+				}
+				for _, l := range issues[i].Labels {
+					if !found[l.Name] {
+						found[l.Name] = true
+						answer = append(answer, l.Name)
+					}
+				}
+			}
+		}
+	}
+	return answer
+}
+
+// HasChanges returns true if there are any visible commits, issues or pull requests in the changelog
+func (c *Changelog) HasChanges() bool {
+	for _, g := range c.Groups {
+		if !g.Hidden {
+			return true
+		}
+	}
+	return len(c.Issues) > 0 || len(c.PullRequests) > 0
+}
+
+func firstLine(text string) string {
+	return strings.Split(strings.TrimSpace(text), "\n")[0]
+}
@@ -0,0 +1,228 @@
+//go:build unit
+
+package gits_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChangelog(t *testing.T) {
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "1.2.3",
+		Commits: []v1.CommitSummary{
+			{
+				Message:  "fix(cheese): some fix\n\nfixes #123",
+				SHA:      "123",
+				IssueIDs: []string{"123"},
+			},
+			{
+				Message: "feat: some feature\n\nBREAKING CHANGE: the API changed",
+				SHA:     "456",
+				Author: &v1.UserDetails{
+					Login: "jstrachan",
+				},
+			},
+			{
+				Message: "just some change",
+				SHA:     "789",
+			},
+		},
+		Issues: []v1.IssueSummary{
+			{
+				ID:    "123",
+				Title: "something is broken",
+			},
+		},
+	}
+
+	changelog := gits.NewChangelog(releaseSpec)
+	assert.Equal(t, "1.2.3", changelog.Version)
+	assert.True(t, changelog.HasChanges())
+
+	var titles []string
+	for _, g := range changelog.Groups {
+		titles = append(titles, g.Title)
+	}
+	assert.Equal(t, []string{"BREAKING CHANGES", "New Features", "Bug Fixes", "Other Changes"}, titles)
+
+	fix := changelog.Groups[2].Entries[0]
+	assert.Equal(t, "fix", fix.Type)
+	assert.Equal(t, "cheese", fix.Scope)
+	assert.Equal(t, "some fix", fix.Description)
+	assert.Equal(t, []string{"123"}, fix.IssueIDs)
+
+	require.Len(t, changelog.BreakingChanges, 1)
+	breaking := changelog.BreakingChanges[0]
+	assert.Equal(t, "the API changed", breaking.Description)
+	assert.Equal(t, "456", breaking.SHA)
+	assert.Equal(t, "jstrachan", breaking.Author.Login)
+
+	data, err := json.Marshal(changelog)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"breakingChanges":[{"sha":"456","type":"break"`)
+}
+
+func TestGenerateMarkdownFromTemplate(t *testing.T) {
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "2.0.0",
+		Commits: []v1.CommitSummary{
+			{
+				Message:  "fix: some fix\n\nfixes #123",
+				SHA:      "123",
+				IssueIDs: []string{"123"},
+				Author: &v1.UserDetails{
+					Login: "jstrachan",
+				},
+			},
+			{
+				Message: "feat(ui): some feature",
+				SHA:     "456",
+			},
+		},
+		Issues: []v1.IssueSummary{
+			{
+				ID:    "123",
+				Title: "something is broken",
+				URL:   "https://github.com/jstrachan/foo/issues/123",
+			},
+		},
+		DependencyUpdates: []v1.DependencyUpdate{
+			{
+				DependencyUpdateDetails: v1.DependencyUpdateDetails{
+					Component:   "bar",
+					FromVersion: "1.0.0",
+					ToVersion:   "1.1.0",
+				},
+			},
+		},
+	}
+	gitInfo := &giturl.GitRepository{
+		Host:         "github.com",
+		Organisation: "jstrachan",
+		Name:         "foo",
+	}
+	templateText := `# {{ .Version }}
+{{ range .Groups }}
+## {{ .Title }}
+{{ range .Entries }}
+- {{ if .Scope }}**{{ .Scope }}** {{ end }}{{ .Description }}{{ with .Author }} by {{ userLink . }}{{ end }}{{ range .IssueIDs }} {{ issueLink (issue .) }}{{ end }}
+{{- end }}
+{{ end }}
+{{- range .DependencyUpdates }}
+- {{ .Component }} {{ .FromVersion }} -> {{ .ToVersion }}
+{{- end }}
+`
+	markdown, err := gits.GenerateMarkdownFromTemplate(gits.NewChangelog(releaseSpec), gitInfo, templateText)
+	require.NoError(t, err)
+
+	expectedMarkdown := `# 2.0.0
+
+## New Features
+
+- **ui** some feature
+
+## Bug Fixes
+
+- some fix by [jstrachan](https://github.com/jstrachan) [#123](https://github.com/jstrachan/foo/issues/123)
+
+- bar 1.0.0 -> 1.1.0
+`
+	assert.Equal(t, expectedMarkdown, markdown)
+}
+
+func TestGenerateMarkdownFromTemplateHiddenGroups(t *testing.T) {
+	g := gits.NewGenerator()
+	g.ConfigureCommitGroups([]gits.CommitGroupConfig{
+		{Type: "feat", Title: "Features", Aliases: []string{"feature"}},
+		{Type: "break", Title: "Breaking Changes", Hidden: true},
+	}, []string{"chore"})
+
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "2.0.0",
+		Commits: []v1.CommitSummary{
+			{Message: "chore: tidy up", SHA: "123"},
+			{Message: "feature: via alias", SHA: "456"},
+			{Message: "fix: change the API\n\nBREAKING CHANGE: the API changed", SHA: "789"},
+		},
+	}
+	templateText := `{{ range .Groups }}{{ .Title }}:{{ range .Entries }} {{ .Description }}{{ end }}
+{{ end }}breaking: {{ len .BreakingChanges }}
+`
+	markdown, err := gits.GenerateMarkdownFromTemplate(g.NewChangelog(releaseSpec), generatorGitInfo, templateText)
+	require.NoError(t, err)
+
+	expectedMarkdown := `Features: via alias
+Bug Fixes: change the API
+breaking: 0
+`
+	assert.Equal(t, expectedMarkdown, markdown, "hidden groups and their breaking changes are not passed to the template")
+}
+
+func TestGenerateMarkdownLinksIssuesOnly(t *testing.T) {
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "2.0.0",
+		Commits: []v1.CommitSummary{
+			{
+				Message:  "fix: some fix",
+				SHA:      "123",
+				IssueIDs: []string{"12", "123"},
+			},
+		},
+		Issues: []v1.IssueSummary{
+			{
+				ID:    "123",
+				Title: "something is broken",
+				URL:   "https://github.com/jstrachan/foo/issues/123",
+			},
+		},
+		PullRequests: []v1.IssueSummary{
+			{
+				ID:    "12",
+				Title: "fix: some fix",
+				URL:   "https://github.com/jstrachan/foo/pull/12",
+			},
+		},
+	}
+	markdown, err := gits.GenerateMarkdown(releaseSpec, generatorGitInfo, "", "", false, false)
+	require.NoError(t, err)
+
+	expectedMarkdown := `## Changes in version 2.0.0
+
+### Bug Fixes
+
+* some fix [#123](https://github.com/jstrachan/foo/issues/123) 
+
+### Issues
+
+* [#123](https://github.com/jstrachan/foo/issues/123) something is broken
+`
+	assert.Equal(t, expectedMarkdown, markdown, "the commits only link to issues as pull requests are listed separately")
+}
+
+func TestGenerateMarkdownFromTemplateDuplicateCommits(t *testing.T) {
+	author := &v1.UserDetails{Login: "jstrachan"}
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "2.0.0",
+		Commits: []v1.CommitSummary{
+			{Message: "fix: some fix", SHA: "123", Author: author},
+			{Message: "fix: some fix", SHA: "456", Author: author},
+			{Message: "fix: some fix", SHA: "789", Author: &v1.UserDetails{Login: "rawlingsj"}},
+		},
+	}
+	templateText := `{{ range .Groups }}{{ range .Entries }}- {{ .Description }} {{ .SHA }}
+{{ end }}{{ end }}`
+	markdown, err := gits.GenerateMarkdownFromTemplate(gits.NewChangelog(releaseSpec), generatorGitInfo, templateText)
+	require.NoError(t, err)
+
+	expectedMarkdown := `- some fix 123
+- some fix 789
+`
+	assert.Equal(t, expectedMarkdown, markdown, "commits described the same way are only included once")
+}
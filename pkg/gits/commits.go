@@ -21,13 +21,13 @@ type CommitInfo struct {
 }
 
 type CommitGroup struct {
-	Title string
-	Order int
+	Title  string
+	Order  int
+	Hidden bool
 }
 
 var (
-	groupCounter          = 0
-	undefinedGroupCounter = 0
+	groupCounter = 0
 
 	// ConventionalCommitTitles textual descriptions for
 	// Conventional Commit types: https://conventionalcommits.org/
@@ -45,7 +45,6 @@ var (
 		"":         createCommitGroup("Other Changes"),
 	}
 
-	unknownKindOrder         = ConventionalCommitTitles[""].Order
 	ConventionalCommitRegexp = regexp.MustCompile(`^([0-9A-Za-z-]+)(?:\(([0-9A-Za-z-]+)\))?(!)?: (.+)((?s:.*))`)
 	BreakingChangeRegexp     = regexp.MustCompile(`(?m)^BREAKING CHANGE: (.*)`)
 )
@@ -86,6 +85,7 @@ func ParseCommit(message string) (*CommitInfo, *CommitInfo) {
 	return answer, nil
 }
 
+// Group returns the default group of the commit type. Use a Generator to take custom groups into account
 func (c *CommitInfo) Group() *CommitGroup {
 	if c.group == nil {
 		title, found := ConventionalCommitTitles[strings.ToLower(c.Type)]
@@ -94,13 +94,10 @@ func (c *CommitInfo) Group() *CommitGroup {
 		} else {
 			// Put unknown kinds first with the idea that if you invent
 			// something for yourself it's probably important for you.
-			undefinedGroupCounter--
-			newGroup := &CommitGroup{
+			c.group = &CommitGroup{
 				Title: c.Type,
-				Order: undefinedGroupCounter,
+				Order: -1,
 			}
-			ConventionalCommitTitles[strings.ToLower(c.Type)] = newGroup
-			c.group = newGroup
 		}
 	}
 	return c.group
@@ -114,16 +111,12 @@ func (c *CommitInfo) Order() int {
 	return c.Group().Order
 }
 
-type GroupAndCommitInfos struct {
-	group   *CommitGroup
-	commits *linkedhashset.Set // duplicate commit messages should not show up in changelog
-}
-
-// GenerateMarkdown generates the markdown document for the commits
+// GenerateMarkdown generates the markdown document for the commits using the default conventional commit groups
 func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository, changelogSeparator, changelogOutputSeparator string, prchangelog, includeprs bool) (string, error) {
-	var hasCommitInfos bool
+	return NewGenerator().GenerateMarkdown(releaseSpec, gitInfo, changelogSeparator, changelogOutputSeparator, prchangelog, includeprs)
+}
 
-	groupAndCommits := map[int]*GroupAndCommitInfos{}
+func generateMarkdown(changelog *Changelog, releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository, changelogSeparator, changelogOutputSeparator string, prchangelog, includeprs bool) (string, error) {
 
 	issues := releaseSpec.Issues
 	issueMap := map[string]*v1.IssueSummary{}
@@ -132,37 +125,28 @@ func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository
 		issueMap[cp.ID] = &cp
 	}
 
-	for i := range releaseSpec.Commits {
-		cs := &releaseSpec.Commits[i]
-		message := cs.Message
-		if message != "" {
-			ci, bc := ParseCommit(message)
-
-			addCommitToGroup(gitInfo, cs, ci, issueMap, groupAndCommits)
-			if bc != nil {
-				addCommitToGroup(gitInfo, cs, bc, issueMap, groupAndCommits)
-			}
-			hasCommitInfos = true
-		}
-	}
-
 	prs := releaseSpec.PullRequests
 
 	var buffer bytes.Buffer
-	if !hasCommitInfos && len(issues) == 0 && len(prs) == 0 {
+	if !changelog.HasChanges() {
 		return "", nil
 	}
 
 	buffer.WriteString("## Changes in version " + releaseSpec.Version + "\n")
 
 	hasTitle := false
-	for i := undefinedGroupCounter; i <= unknownKindOrder; i++ {
-		gac := groupAndCommits[i]
-		if gac != nil && len(gac.commits.Values()) > 0 {
-			hasTitle = writeCommitGroupHeader(gac.group, &buffer, i == unknownKindOrder, hasTitle)
-			for _, msg := range gac.commits.Values() {
-				buffer.WriteString(msg.(string))
-			}
+	for _, group := range changelog.Groups {
+		if group.Hidden {
+			continue
+		}
+		// duplicate commit messages should not show up in changelog
+		commits := linkedhashset.New()
+		for _, entry := range group.Entries {
+			commits.Add("* " + describeCommit(gitInfo, entry.commit, entry.info, issueMap) + "\n")
+		}
+		hasTitle = writeCommitGroupHeader(group.Title, &buffer, group.Type == "", hasTitle)
+		for _, msg := range commits.Values() {
+			buffer.WriteString(msg.(string))
 		}
 	}
 
@@ -202,34 +186,18 @@ func GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository
 	return buffer.String(), nil
 }
 
-func writeCommitGroupHeader(group *CommitGroup, buffer *bytes.Buffer, kindUnknown, hasTitle bool) bool {
-	if group != nil {
-		buffer.WriteString("\n")
-		if !kindUnknown || hasTitle {
-			hasTitle = true
-			buffer.WriteString("### " + group.Title + "\n\n")
-			if kindUnknown {
-				buffer.WriteString("These commits did not use [Conventional Commits](https://conventionalcommits.org/) formatted messages:\n\n")
-			}
+func writeCommitGroupHeader(title string, buffer *bytes.Buffer, kindUnknown, hasTitle bool) bool {
+	buffer.WriteString("\n")
+	if !kindUnknown || hasTitle {
+		hasTitle = true
+		buffer.WriteString("### " + title + "\n\n")
+		if kindUnknown {
+			buffer.WriteString("These commits did not use [Conventional Commits](https://conventionalcommits.org/) formatted messages:\n\n")
 		}
 	}
 	return hasTitle
 }
 
-func addCommitToGroup(gitInfo *giturl.GitRepository, commits *v1.CommitSummary, ci *CommitInfo, issueMap map[string]*v1.IssueSummary, groupAndCommits map[int]*GroupAndCommitInfos) {
-	description := "* " + describeCommit(gitInfo, commits, ci, issueMap) + "\n"
-	group := ci.Group()
-	gac := groupAndCommits[group.Order]
-	if gac == nil {
-		gac = &GroupAndCommitInfos{
-			group:   group,
-			commits: linkedhashset.New(),
-		}
-		groupAndCommits[group.Order] = gac
-	}
-	gac.commits.Add(description)
-}
-
 func describeIssue(info *giturl.GitRepository, issue *v1.IssueSummary) string {
 	return "* " + describeIssueShort(issue) + issue.Title + describeUser(info, issue.User) + "\n"
 }
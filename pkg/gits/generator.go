@@ -0,0 +1,124 @@
+package gits
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+)
+
+// Generator generates changelogs using its own registry of commit groups.
+//
+// A Generator is safe for concurrent use. Commit types which are not known to the generator only affect the
+// changelog they are found in.
+type Generator struct {
+	lock            sync.RWMutex
+	groups          map[string]CommitGroup
+	aliases         map[string]string
+	labels          map[string]string
+	skipLabels      map[string]bool
+	labelPrecedence bool
+}
+
+// NewGenerator creates a generator with the default Conventional Commit groups
+func NewGenerator() *Generator {
+	groups := map[string]CommitGroup{}
+	for t, group := range ConventionalCommitTitles {
+		groups[t] = *group
+	}
+	return &Generator{
+		groups:     groups,
+		aliases:    map[string]string{},
+		labels:     map[string]string{},
+		skipLabels: map[string]bool{DefaultSkipLabel: true},
+	}
+}
+
+// CanonicalCommitType returns the lower case commit type after resolving any alias
+func (g *Generator) CanonicalCommitType(commitType string) string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.canonicalCommitType(commitType)
+}
+
+func (g *Generator) canonicalCommitType(commitType string) string {
+	t := strings.ToLower(commitType)
+	if alias, ok := g.aliases[t]; ok {
+		return alias
+	}
+	return t
+}
+
+// CommitGroup returns a copy of the group for the given commit type
+func (g *Generator) CommitGroup(commitType string) (CommitGroup, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	group, ok := g.groups[g.canonicalCommitType(commitType)]
+	return group, ok
+}
+
+// NewChangelog parses the commits of the release and groups them by their conventional commit type
+func (g *Generator) NewChangelog(releaseSpec *v1.ReleaseSpec) *Changelog {
+	answer := &Changelog{
+		Version:           releaseSpec.Version,
+		Issues:            releaseSpec.Issues,
+		PullRequests:      releaseSpec.PullRequests,
+		DependencyUpdates: releaseSpec.DependencyUpdates,
+	}
+	groups := map[string]*ChangelogGroup{}
+	undefinedGroupCounter := 0
+	groupFor := func(ci *CommitInfo) *ChangelogGroup {
+		t := g.CanonicalCommitType(ci.Type)
+		cg := groups[t]
+		if cg != nil {
+			return cg
+		}
+		group, found := g.CommitGroup(t)
+		if !found {
+			// Put unknown kinds first with the idea that if you invent
+			// something for yourself it's probably important for you.
+			undefinedGroupCounter--
+			group = CommitGroup{
+				Title: ci.Type,
+				Order: undefinedGroupCounter,
+			}
+		}
+		cg = &ChangelogGroup{
+			Type:   t,
+			Title:  group.Title,
+			Order:  group.Order,
+			Hidden: group.Hidden,
+		}
+		groups[t] = cg
+		answer.Groups = append(answer.Groups, cg)
+		return cg
+	}
+
+	for i := range releaseSpec.Commits {
+		cs := &releaseSpec.Commits[i]
+		if cs.Message == "" {
+			continue
+		}
+		labels := answer.labels(cs.IssueIDs)
+		if g.skipped(labels) {
+			continue
+		}
+		ci, bc := ParseCommit(cs.Message)
+		ci.Type = g.commitType(ci, labels)
+		answer.addEntry(groupFor(ci), cs, ci, labels)
+		if bc != nil {
+			answer.addEntry(groupFor(bc), cs, bc, labels)
+		}
+	}
+	sort.SliceStable(answer.Groups, func(i, j int) bool {
+		return answer.Groups[i].Order < answer.Groups[j].Order
+	})
+	return answer
+}
+
+// GenerateMarkdown generates the markdown document for the commits
+func (g *Generator) GenerateMarkdown(releaseSpec *v1.ReleaseSpec, gitInfo *giturl.GitRepository, changelogSeparator, changelogOutputSeparator string, prchangelog, includeprs bool) (string, error) {
+	return generateMarkdown(g.NewChangelog(releaseSpec), releaseSpec, gitInfo, changelogSeparator, changelogOutputSeparator, prchangelog, includeprs)
+}
@@ -0,0 +1,182 @@
+//go:build unit
+
+package gits_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var generatorGitInfo = &giturl.GitRepository{
+	Host:         "github.com",
+	Organisation: "jstrachan",
+	Name:         "foo",
+}
+
+func TestGeneratorCustomGroups(t *testing.T) {
+	t.Parallel()
+	g := gits.NewGenerator()
+	g.ConfigureCommitGroups([]gits.CommitGroupConfig{
+		{Type: "security", Title: "Security Fixes"},
+		{Type: "feat", Title: "Features", Aliases: []string{"feature"}},
+		{Type: "deps", Title: "Dependencies", Order: 10},
+	}, []string{"chore"})
+
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "1.0.0",
+		Commits: []v1.CommitSummary{
+			{Message: "fix: a fix"},
+			{Message: "chore: tidy up"},
+			{Message: "feature: via alias"},
+			{Message: "deps: bump foo"},
+			{Message: "feat: new thing"},
+			{Message: "security: patch CVE"},
+			{Message: "no convention"},
+		},
+	}
+
+	changelog := g.NewChangelog(releaseSpec)
+	var types []string
+	for _, group := range changelog.Groups {
+		types = append(types, group.Type)
+	}
+	assert.Equal(t, []string{"security", "feat", "deps", "fix", "chore", ""}, types)
+	assert.Len(t, changelog.Groups[1].Entries, 2, "the alias should be merged into feat")
+	assert.True(t, changelog.Groups[4].Hidden, "chore should be hidden")
+
+	markdown, err := g.GenerateMarkdown(releaseSpec, generatorGitInfo, "", "", false, false)
+	require.NoError(t, err)
+	expectedMarkdown := `## Changes in version 1.0.0
+
+### Security Fixes
+
+* patch CVE
+
+### Features
+
+* via alias
+* new thing
+
+### Dependencies
+
+* bump foo
+
+### Bug Fixes
+
+* a fix
+
+### Other Changes
+
+These commits did not use [Conventional Commits](https://conventionalcommits.org/) formatted messages:
+
+* no convention
+`
+	assert.Equal(t, expectedMarkdown, markdown)
+}
+
+func TestGeneratorsAreIndependent(t *testing.T) {
+	t.Parallel()
+	releaseSpec := &v1.ReleaseSpec{
+		Commits: []v1.CommitSummary{
+			{Message: "wip: something"},
+			{Message: "feat: new thing"},
+		},
+	}
+
+	custom := gits.NewGenerator()
+	custom.ConfigureCommitGroups([]gits.CommitGroupConfig{{Type: "wip", Title: "Work In Progress"}}, nil)
+	assert.Equal(t, "Work In Progress", custom.NewChangelog(releaseSpec).Groups[0].Title)
+
+	changelog := gits.NewGenerator().NewChangelog(releaseSpec)
+	require.Len(t, changelog.Groups, 2)
+	assert.Equal(t, "wip", changelog.Groups[0].Title)
+	assert.Equal(t, -1, changelog.Groups[0].Order)
+	assert.Equal(t, "New Features", changelog.Groups[1].Title)
+}
+
+func TestGeneratorConcurrentUse(t *testing.T) {
+	t.Parallel()
+	g := gits.NewGenerator()
+	g.ConfigureCommitGroups([]gits.CommitGroupConfig{{Type: "feat", Aliases: []string{"feature"}}}, []string{"test"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			releaseSpec := &v1.ReleaseSpec{
+				Commits: []v1.CommitSummary{
+					{Message: "custom: something"},
+					{Message: "feature: new thing"},
+					{Message: "test: more tests"},
+				},
+			}
+			markdown, err := g.GenerateMarkdown(releaseSpec, generatorGitInfo, "", "", false, false)
+			assert.NoError(t, err)
+			assert.Contains(t, markdown, "### custom")
+			assert.NotContains(t, markdown, "more tests")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGeneratorLabels(t *testing.T) {
+	t.Parallel()
+	releaseSpec := &v1.ReleaseSpec{
+		Version: "1.0.0",
+		Commits: []v1.CommitSummary{
+			{Message: "Fix the login page", IssueIDs: []string{"1"}},
+			{Message: "feat: a new button", IssueIDs: []string{"2"}},
+			{Message: "update the readme", IssueIDs: []string{"3"}},
+			{Message: "fix: flaky test", IssueIDs: []string{"4"}},
+			{Message: "no labels"},
+		},
+		PullRequests: []v1.IssueSummary{
+			{ID: "1", Labels: []v1.IssueLabel{{Name: "kind/bug"}}},
+			{ID: "2", Labels: []v1.IssueLabel{{Name: "area/ui"}}},
+			{ID: "4", Labels: []v1.IssueLabel{{Name: "skip-changelog"}}},
+		},
+		Issues: []v1.IssueSummary{
+			{ID: "3", Labels: []v1.IssueLabel{{Name: "Kind/Documentation"}}},
+		},
+	}
+	groups := []gits.CommitGroupConfig{
+		{Type: "fix", Labels: []string{"kind/bug"}},
+		{Type: "ui", Title: "User Interface", Labels: []string{"area/ui"}},
+		{Type: "docs", Labels: []string{"kind/documentation"}},
+	}
+	sections := func(g *gits.Generator) map[string][]string {
+		answer := map[string][]string{}
+		for _, group := range g.NewChangelog(releaseSpec).Groups {
+			for _, entry := range group.Entries {
+				answer[group.Type] = append(answer[group.Type], entry.Description)
+			}
+		}
+		return answer
+	}
+
+	g := gits.NewGenerator()
+	g.ConfigureCommitGroups(groups, nil)
+	assert.Equal(t, map[string][]string{
+		"fix":  {"Fix the login page"},
+		"feat": {"a new button"},
+		"docs": {"update the readme"},
+		"":     {"no labels"},
+	}, sections(g), "the commit type should take precedence and skip-changelog should be skipped")
+
+	g = gits.NewGenerator()
+	g.ConfigureCommitGroups(groups, nil)
+	g.ConfigureLabels([]string{"wontfix"}, gits.PrecedenceLabel)
+	assert.Equal(t, map[string][]string{
+		"fix":  {"Fix the login page", "flaky test"},
+		"ui":   {"a new button"},
+		"docs": {"update the readme"},
+		"":     {"no labels"},
+	}, sections(g), "the labels should take precedence")
+}
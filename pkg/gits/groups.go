@@ -0,0 +1,102 @@
+package gits
+
+import (
+	"sort"
+	"strings"
+)
+
+// CommitGroupConfig configures how a conventional commit type is shown in the changelog
+type CommitGroupConfig struct {
+	// Type the conventional commit type such as feat or fix
+	Type string `json:"type"`
+
+	// Title the title of the section in the changelog
+	Title string `json:"title,omitempty"`
+
+	// Order the position of the section in the changelog. Defaults to the position in the configuration
+	Order int `json:"order,omitempty"`
+
+	// Aliases other commit types which are merged into this type such as feature for feat
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Hidden if true the commits are left out of the generated markdown but still included in the Release
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Labels the pull request or issue labels such as kind/bug which put commits in this section
+	Labels []string `json:"labels,omitempty"`
+}
+
+// ConfigureCommitGroups changes the titles and order of the commit groups and declares new ones.
+//
+// The given groups come first in their configured order followed by any other known types in their current
+// order. Commits which don't use conventional commits are always last. The hidden types are still parsed and
+// grouped but are left out of the generated markdown. Commits whose pull request or issues have one of the labels
+// of a group are put in that group, see ConfigureLabels for when labels take precedence over the commit type.
+func (g *Generator) ConfigureCommitGroups(groups []CommitGroupConfig, hiddenTypes []string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	order := 0
+	configured := map[string]bool{}
+	for _, gc := range groups {
+		t := strings.ToLower(gc.Type)
+		if t == "" || configured[t] {
+			continue
+		}
+		group, found := g.groups[t]
+		if !found {
+			group = CommitGroup{Title: gc.Type}
+		}
+		if gc.Title != "" {
+			group.Title = gc.Title
+		}
+		if gc.Order > 0 {
+			order = gc.Order
+		} else {
+			order++
+		}
+		group.Order = order
+		group.Hidden = gc.Hidden
+		g.groups[t] = group
+		for _, alias := range gc.Aliases {
+			alias = strings.ToLower(alias)
+			g.aliases[alias] = t
+			delete(g.groups, alias)
+		}
+		for _, label := range gc.Labels {
+			g.labels[strings.ToLower(label)] = t
+		}
+		configured[t] = true
+	}
+
+	maxOrder := 0
+	var others []string
+	for t, group := range g.groups {
+		if configured[t] {
+			if group.Order > maxOrder {
+				maxOrder = group.Order
+			}
+		} else if t != "" {
+			others = append(others, t)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return g.groups[others[i]].Order < g.groups[others[j]].Order
+	})
+	for _, t := range append(others, "") {
+		group := g.groups[t]
+		maxOrder++
+		group.Order = maxOrder
+		g.groups[t] = group
+	}
+
+	for _, t := range hiddenTypes {
+		t = g.canonicalCommitType(t)
+		group, ok := g.groups[t]
+		if !ok {
+			group = CommitGroup{Title: t, Order: -1}
+		}
+		group.Hidden = true
+		g.groups[t] = group
+	}
+}
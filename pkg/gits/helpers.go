@@ -52,13 +52,16 @@ func NTags(g gitclient.Interface, dir string, n int, prefix string) ([][]string,
 		return nil, fmt.Errorf("running git %s: %w", strings.Join(args, " "), err)
 	}
 
+	if out == "" {
+		return nil, nil
+	}
 	tagList := strings.Split(out, "\n")
 	res := make([][]string, len(tagList))
 	for i, tag := range tagList {
 		fields := strings.Split(tag, "\x00")
 
 		if len(fields) != 2 {
-			return nil, fmt.Errorf("unexpected format for returned tag and sha: '%s'", tagList[n-1])
+			return nil, fmt.Errorf("unexpected format for returned tag and sha: '%s'", tag)
 		}
 		res[i] = fields
 	}
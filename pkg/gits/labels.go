@@ -0,0 +1,68 @@
+package gits
+
+import (
+	"strings"
+)
+
+const (
+	// DefaultSkipLabel the label of pull requests and issues whose commits are left out of the changelog
+	DefaultSkipLabel = "skip-changelog"
+
+	// PrecedenceType the conventional commit type decides the section. Labels are only used for commits without a
+	// known type
+	PrecedenceType = "type"
+
+	// PrecedenceLabel a label mapped to a section decides the section even if the commit has a known type
+	PrecedenceLabel = "label"
+)
+
+// ConfigureLabels changes the labels which leave commits out of the changelog and whether labels or conventional
+// commit types take precedence when they disagree. The default skip label is used if none are specified.
+//
+// Breaking changes always stay in the breaking changes section.
+func (g *Generator) ConfigureLabels(skipLabels []string, precedence string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if len(skipLabels) == 0 {
+		skipLabels = []string{DefaultSkipLabel}
+	}
+	g.skipLabels = map[string]bool{}
+	for _, l := range skipLabels {
+		g.skipLabels[strings.ToLower(l)] = true
+	}
+	g.labelPrecedence = precedence == PrecedenceLabel
+}
+
+// skipped returns true if any of the labels leaves the commit out of the changelog
+func (g *Generator) skipped(labels []string) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	for _, l := range labels {
+		if g.skipLabels[strings.ToLower(l)] {
+			return true
+		}
+	}
+	return false
+}
+
+// commitType returns the type of the group the commit belongs in taking the labels of its pull request and issues
+// into account
+func (g *Generator) commitType(ci *CommitInfo, labels []string) string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	t := g.canonicalCommitType(ci.Type)
+	if t == "break" {
+		return ci.Type
+	}
+	if _, known := g.groups[t]; known && t != "" && !g.labelPrecedence {
+		return ci.Type
+	}
+	for _, l := range labels {
+		if labelType, ok := g.labels[strings.ToLower(l)]; ok {
+			return labelType
+		}
+	}
+	return ci.Type
+}
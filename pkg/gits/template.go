@@ -0,0 +1,120 @@
+package gits
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
+)
+
+// GenerateMarkdownFromTemplate renders the whole changelog using the given go template instead of the built-in layout.
+//
+// The template is executed with the *Changelog as its data, so the following are available:
+//
+//	.Version, .Tag, .PreviousRevision, .CurrentRevision
+//	.Groups            the commit groups in order without the hidden ones; each has .Type, .Title, .Order and .Entries
+//	                   without duplicate commits in the same way as the default layout
+//	.BreakingChanges   the entries which are breaking changes
+//	.Issues            the issues (v1.IssueSummary) referenced by the commits
+//	.PullRequests      the pull requests (v1.IssueSummary) referenced by the commits
+//	.DependencyUpdates the dependency updates (v1.DependencyUpdate) with .Component, .URL, .FromVersion and .ToVersion
+//
+// Each entry has .SHA, .Type, .Scope, .Description, .Breaking, .Message, .Author, .IssueIDs, .Labels and the
+// .PullRequest it was merged by if known.
+//
+// The .ReleaseNotesURL is not known yet as the rendered changelog is published as the release notes.
+//
+// The following functions can be used in the template as well:
+//
+//	issue ID          returns the issue or pull request with the given ID or nil
+//	issueLink ISSUE   returns a markdown link to the issue or pull request
+//	userLink USER     returns a markdown link to the user
+//	firstLine TEXT    returns the first line of the text
+//	join SEP LIST     joins a list of strings with the separator
+func GenerateMarkdownFromTemplate(changelog *Changelog, gitInfo *giturl.GitRepository, templateText string) (string, error) {
+	funcMap := template.FuncMap{
+		"issue": changelog.Issue,
+		"issueLink": func(issue *v1.IssueSummary) string {
+			if issue == nil {
+				return ""
+			}
+			return strings.TrimSpace(describeIssueShort(issue))
+		},
+		"userLink": func(user *v1.UserDetails) string {
+			text := strings.TrimSpace(describeUser(gitInfo, user))
+			return strings.TrimSuffix(strings.TrimPrefix(text, "("), ")")
+		},
+		"firstLine": firstLine,
+		"join": func(sep string, values []string) string {
+			return strings.Join(values, sep)
+		},
+	}
+	tmpl, err := template.New("changelog").Funcs(funcMap).Parse(templateText)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, visibleChangelog(changelog, gitInfo))
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// visibleChangelog returns a copy of the changelog without the hidden groups and their breaking changes. Commits
+// which would be described the same way by the default layout are only included once per group
+func visibleChangelog(changelog *Changelog, gitInfo *giturl.GitRepository) *Changelog {
+	answer := *changelog
+	answer.Groups = nil
+	answer.BreakingChanges = nil
+	issueMap := map[string]*v1.IssueSummary{}
+	for k := range changelog.Issues {
+		issueMap[changelog.Issues[k].ID] = &changelog.Issues[k]
+	}
+	visible := map[*ChangelogEntry]bool{}
+	for _, group := range changelog.Groups {
+		if group.Hidden {
+			continue
+		}
+		g := *group
+		g.Entries = nil
+		described := map[string]bool{}
+		for _, entry := range group.Entries {
+			text := entry.Description
+			if entry.commit != nil && entry.info != nil {
+				text = describeCommit(gitInfo, entry.commit, entry.info, issueMap)
+			}
+			if described[text] {
+				continue
+			}
+			described[text] = true
+			g.Entries = append(g.Entries, entry)
+			visible[entry] = true
+		}
+		answer.Groups = append(answer.Groups, &g)
+	}
+	for _, entry := range changelog.BreakingChanges {
+		if visible[entry] {
+			answer.BreakingChanges = append(answer.BreakingChanges, entry)
+		}
+	}
+	return &answer
+}
+
+// Issue returns the issue or pull request with the given ID or nil if it cannot be found
+func (c *Changelog) Issue(id string) *v1.IssueSummary {
+	id = strings.TrimPrefix(id, "#")
+	for k := range c.Issues {
+		if c.Issues[k].ID == id {
+			return &c.Issues[k]
+		}
+	}
+	for k := range c.PullRequests {
+		if c.PullRequests[k].ID == id {
+			return &c.PullRequests[k]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package issues
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+const (
+	azureAPIVersion        = "7.1"
+	azureCommentAPIVersion = "7.1-preview.4"
+)
+
+// AzureClosedStates the default states of closed work items
+var AzureClosedStates = []string{"Closed", "Done", "Removed", "Resolved"}
+
+// AzureService an issue provider for Azure DevOps Boards work items
+type AzureService struct {
+	HTTPClient *http.Client
+
+	// ServerURL the URL of the organization such as https://dev.azure.com/myorg
+	ServerURL string
+	Project   string
+	Token     string
+
+	// WorkItemType the type of new work items
+	WorkItemType string
+
+	// ClosedStates the states of closed work items
+	ClosedStates []string
+}
+
+type azureIdentity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl"`
+	URL         string `json:"url"`
+}
+
+type azureWorkItem struct {
+	ID     int `json:"id"`
+	Fields struct {
+		Title        string         `json:"System.Title"`
+		Description  string         `json:"System.Description"`
+		State        string         `json:"System.State"`
+		WorkItemType string         `json:"System.WorkItemType"`
+		Tags         string         `json:"System.Tags"`
+		AssignedTo   *azureIdentity `json:"System.AssignedTo"`
+		CreatedBy    *azureIdentity `json:"System.CreatedBy"`
+		CreatedDate  time.Time      `json:"System.CreatedDate"`
+		ChangedDate  time.Time      `json:"System.ChangedDate"`
+		ClosedDate   *time.Time     `json:"Microsoft.VSTS.Common.ClosedDate"`
+	} `json:"fields"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"_links"`
+}
+
+type azureComments struct {
+	Comments []struct {
+		Text string `json:"text"`
+	} `json:"comments"`
+}
+
+type azurePatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// AzureOptions the settings to connect to Azure DevOps Boards
+type AzureOptions struct {
+	// ServerURL the URL of the organization such as https://dev.azure.com/myorg
+	ServerURL string
+
+	// Project the name of the project
+	Project string
+
+	// Token the personal access token
+	Token string
+
+	// WorkItemType the type of new work items. Defaults to Bug
+	WorkItemType string
+
+	// ClosedStates the states of closed work items. Defaults to AzureClosedStates
+	ClosedStates []string
+}
+
+// NewAzureIssueProvider creates an issue provider for the work items of the project in the Azure DevOps
+// organization authenticating with a personal access token
+func NewAzureIssueProvider(o *AzureOptions) (IssueProvider, error) {
+	if o.ServerURL == "" {
+		return nil, fmt.Errorf("no Azure DevOps organization URL")
+	}
+	if o.Project == "" {
+		return nil, fmt.Errorf("no Azure DevOps project")
+	}
+	answer := &AzureService{
+		HTTPClient:   newHTTPClient(),
+		ServerURL:    strings.TrimSuffix(o.ServerURL, "/"),
+		Project:      o.Project,
+		Token:        o.Token,
+		WorkItemType: o.WorkItemType,
+		ClosedStates: o.ClosedStates,
+	}
+	if answer.WorkItemType == "" {
+		answer.WorkItemType = "Bug"
+	}
+	if len(answer.ClosedStates) == 0 {
+		answer.ClosedStates = AzureClosedStates
+	}
+	return answer, nil
+}
+
+func (i *AzureService) GetIssue(key string) (*scm.Issue, error) {
+	id, err := azureWorkItemID(key)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("$expand", "links")
+	workItem := &azureWorkItem{}
+	err = i.do(http.MethodGet, "_apis/wit/workitems/"+strconv.Itoa(id), params, nil, nil, workItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find work item %d: %w", id, err)
+	}
+	return i.azureToGitIssue(workItem), nil
+}
+
+func (i *AzureService) SearchIssues(query string) ([]*scm.Issue, error) {
+	wiql := "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.State] NOT IN (" + i.quotedClosedStates() + ")"
+	if query != "" {
+		wiql += " AND [System.Title] CONTAINS " + azureQuote(query)
+	}
+	return i.query(wiql)
+}
+
+func (i *AzureService) SearchIssuesClosedSince(t time.Time) ([]*scm.Issue, error) {
+	wiql := "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = @project AND [System.State] IN (" + i.quotedClosedStates() + ") AND [System.ChangedDate] >= " + azureQuote(t.UTC().Format("2006-01-02"))
+	return i.query(wiql)
+}
+
+// query finds the work items matching the WIQL query
+func (i *AzureService) query(wiql string) ([]*scm.Issue, error) {
+	result := &struct {
+		WorkItems []struct {
+			ID int `json:"id"`
+		} `json:"workItems"`
+	}{}
+	err := i.do(http.MethodPost, stringhelpers.UrlJoin(url.PathEscape(i.Project), "_apis/wit/wiql"), nil, nil, map[string]string{"query": wiql}, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work items: %w", err)
+	}
+	var answer []*scm.Issue
+	// the work items API returns at most 200 work items at a time
+	for start := 0; start < len(result.WorkItems); start += 200 {
+		end := start + 200
+		if end > len(result.WorkItems) {
+			end = len(result.WorkItems)
+		}
+		var ids []string
+		for _, w := range result.WorkItems[start:end] {
+			ids = append(ids, strconv.Itoa(w.ID))
+		}
+		params := url.Values{}
+		params.Set("ids", strings.Join(ids, ","))
+		params.Set("$expand", "links")
+		workItems := &struct {
+			Value []azureWorkItem `json:"value"`
+		}{}
+		err = i.do(http.MethodGet, "_apis/wit/workitems", params, nil, nil, workItems)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get work items: %w", err)
+		}
+		for k := range workItems.Value {
+			answer = append(answer, i.azureToGitIssue(&workItems.Value[k]))
+		}
+	}
+	return answer, nil
+}
+
+func (i *AzureService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	body := []azurePatchOperation{
+		{Op: "add", Path: "/fields/System.Title", Value: issue.Title},
+		{Op: "add", Path: "/fields/System.Description", Value: issue.Body},
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json-patch+json")
+	workItem := &azureWorkItem{}
+	err := i.do(http.MethodPost, stringhelpers.UrlJoin(url.PathEscape(i.Project), "_apis/wit/workitems", "$"+url.PathEscape(i.WorkItemType)), nil, header, body, workItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work item: %w", err)
+	}
+	return i.azureToGitIssue(workItem), nil
+}
+
+func (i *AzureService) CreateIssueComment(key, comment string) error {
+	id, err := azureWorkItemID(key)
+	if err != nil {
+		return err
+	}
+	err = i.do(http.MethodPost, i.commentsPath(id), i.commentParams(), nil, map[string]string{"text": comment}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add comment to work item %d: %w", id, err)
+	}
+	return nil
+}
+
+func (i *AzureService) GetIssueComments(key string) ([]string, error) {
+	id, err := azureWorkItemID(key)
+	if err != nil {
+		return nil, err
+	}
+	result := &azureComments{}
+	err = i.do(http.MethodGet, i.commentsPath(id), i.commentParams(), nil, nil, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments of work item %d: %w", id, err)
+	}
+	var answer []string
+	for _, c := range result.Comments {
+		answer = append(answer, c.Text)
+	}
+	return answer, nil
+}
+
+func (i *AzureService) commentsPath(id int) string {
+	return stringhelpers.UrlJoin(url.PathEscape(i.Project), "_apis/wit/workItems", strconv.Itoa(id), "comments")
+}
+
+// commentParams the parameters of the comments API so that comments are in markdown rather than HTML
+func (i *AzureService) commentParams() url.Values {
+	params := url.Values{}
+	params.Set("format", "markdown")
+	params.Set("api-version", azureCommentAPIVersion)
+	return params
+}
+
+func (i *AzureService) IssueURL(key string) string {
+	return stringhelpers.UrlJoin(i.ServerURL, url.PathEscape(i.Project), "_workitems/edit", strings.TrimPrefix(key, "AB#"))
+}
+
+func (i *AzureService) HomeURL() string {
+	return stringhelpers.UrlJoin(i.ServerURL, url.PathEscape(i.Project), "_workitems")
+}
+
+// azureToGitIssue converts a work item using its state as the state of the issue and its tags and type as labels
+func (i *AzureService) azureToGitIssue(workItem *azureWorkItem) *scm.Issue {
+	fields := &workItem.Fields
+	answer := &scm.Issue{
+		Number:  workItem.ID,
+		Title:   fields.Title,
+		Body:    fields.Description,
+		Link:    workItem.Links.HTML.Href,
+		State:   fields.State,
+		Created: fields.CreatedDate,
+		Updated: fields.ChangedDate,
+		Closed:  fields.ClosedDate != nil && !fields.ClosedDate.IsZero(),
+	}
+	if answer.Link == "" {
+		answer.Link = i.IssueURL(strconv.Itoa(workItem.ID))
+	}
+	for _, s := range i.ClosedStates {
+		if strings.EqualFold(s, fields.State) {
+			answer.Closed = true
+		}
+	}
+	if answer.State == "" {
+		answer.State = IssueStateOpen
+		if answer.Closed {
+			answer.State = IssueStateClosed
+		}
+	}
+	for _, tag := range strings.Split(fields.Tags, ";") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			answer.Labels = append(answer.Labels, tag)
+		}
+	}
+	if fields.WorkItemType != "" {
+		answer.Labels = append(answer.Labels, AzureTypeLabelPrefix+fields.WorkItemType)
+	}
+	if user := azureIdentityToGitUser(fields.CreatedBy); user != nil {
+		answer.Author = *user
+	}
+	if user := azureIdentityToGitUser(fields.AssignedTo); user != nil {
+		answer.Assignees = []scm.User{*user}
+	}
+	return answer
+}
+
+func azureIdentityToGitUser(identity *azureIdentity) *scm.User {
+	if identity == nil {
+		return nil
+	}
+	answer := &scm.User{
+		Login:  identity.UniqueName,
+		Name:   identity.DisplayName,
+		Avatar: identity.ImageURL,
+		Link:   identity.URL,
+	}
+	if strings.Contains(identity.UniqueName, "@") {
+		answer.Email = identity.UniqueName
+	}
+	return answer
+}
+
+func (i *AzureService) quotedClosedStates() string {
+	var states []string
+	for _, s := range i.ClosedStates {
+		states = append(states, azureQuote(s))
+	}
+	return strings.Join(states, ", ")
+}
+
+// azureQuote quotes a WIQL string literal
+func azureQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
+
+// azureWorkItemID returns the ID of a work item key such as 123 or AB#123
+func azureWorkItemID(key string) (int, error) {
+	return issueKeyToNumber(strings.TrimPrefix(strings.ToUpper(key), "AB#"))
+}
+
+// do invokes the REST API authenticating with the personal access token and decodes the JSON response into result
+// if it is not nil
+func (i *AzureService) do(method, path string, params url.Values, header http.Header, body, result interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if params.Get("api-version") == "" {
+		params.Set("api-version", azureAPIVersion)
+	}
+	if header == nil {
+		header = http.Header{}
+	}
+	if i.Token != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+i.Token)))
+	}
+	u := stringhelpers.UrlJoin(i.ServerURL, path) + "?" + params.Encode()
+	return doJSON(i.HTTPClient, method, u, header, body, result)
+}
@@ -0,0 +1,106 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const azureWorkItemJSON = `{
+  "id": 123,
+  "fields": {
+    "System.Title": "the login page is broken",
+    "System.State": "Resolved",
+    "System.WorkItemType": "Bug",
+    "System.Tags": "ui; regression",
+    "System.CreatedDate": "2021-01-02T10:00:00Z",
+    "System.ChangedDate": "2021-01-04T10:00:00Z",
+    "System.CreatedBy": {"displayName": "James Strachan", "uniqueName": "jstrachan@example.com"},
+    "System.AssignedTo": {"displayName": "James Rawlings", "uniqueName": "rawlingsj@example.com", "imageUrl": "https://dev.azure.com/avatar"}
+  },
+  "_links": {"html": {"href": "https://dev.azure.com/myorg/my%20project/_workitems/edit/123"}}
+}`
+
+func TestAzureGetIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/myorg/_apis/wit/workitems/123", r.URL.Path)
+		assert.Equal(t, "7.1", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte(":token")), r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(azureWorkItemJSON))
+	}))
+	defer server.Close()
+
+	tracker, err := issues.NewAzureIssueProvider(&issues.AzureOptions{
+		ServerURL: server.URL + "/myorg/",
+		Project:   "my project",
+		Token:     "token",
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, tracker.(*issues.AzureService).HTTPClient.Timeout, "requests time out")
+	assert.Equal(t, issues.Azure, issues.GetIssueProvider(tracker))
+	assert.Equal(t, server.URL+"/myorg/my%20project/_workitems/edit/123", tracker.IssueURL("AB#123"))
+
+	issue, err := tracker.GetIssue("AB#123")
+	require.NoError(t, err)
+
+	assert.Equal(t, 123, issue.Number)
+	assert.Equal(t, "the login page is broken", issue.Title)
+	assert.Equal(t, "https://dev.azure.com/myorg/my%20project/_workitems/edit/123", issue.Link)
+	assert.Equal(t, "Resolved", issue.State)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, []string{"ui", "regression", "type/Bug"}, issue.Labels)
+	assert.Equal(t, time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC), issue.Created.UTC())
+	assert.Equal(t, "James Strachan", issue.Author.Name)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "rawlingsj@example.com", issue.Assignees[0].Email)
+}
+
+func TestAzureComments(t *testing.T) {
+	var added []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /myorg/myproject/_apis/wit/workItems/123/comments":
+			assert.Equal(t, "markdown", r.URL.Query().Get("format"))
+			_, _ = w.Write([]byte(`{"totalCount": 1, "comments": [{"id": 1, "text": "first"}]}`))
+		case "POST /myorg/myproject/_apis/wit/workItems/123/comments":
+			body := map[string]string{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			added = append(added, body["text"])
+			_, _ = w.Write([]byte(`{"id": 2}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "TF401232: Work item 999 does not exist."}`))
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.NewAzureIssueProvider(&issues.AzureOptions{
+		ServerURL: server.URL + "/myorg",
+		Project:   "myproject",
+		Token:     "token",
+	})
+	require.NoError(t, err)
+
+	comments, err := tracker.(issues.IssueCommentLister).GetIssueComments("123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, comments)
+
+	err = tracker.CreateIssueComment("AB#123", "Released in version 1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Released in version 1.2.0"}, added)
+
+	err = tracker.CreateIssueComment("999", "hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TF401232")
+}
@@ -0,0 +1,132 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// BatchIssueProvider the v2 issue provider interface which supports contexts with deadlines and looking up several
+// issues at once. Use AsBatchIssueProvider to use any IssueProvider as a BatchIssueProvider
+type BatchIssueProvider interface {
+	IssueProvider
+
+	// GetIssueWithContext returns the issue of the given key
+	GetIssueWithContext(ctx context.Context, key string) (*scm.Issue, error)
+
+	// GetIssues returns the issues of the given keys indexed by key. Keys of issues which do not exist are left out.
+	// If some of the issues could not be looked up the issues which were found are returned together with
+	// IssueErrors
+	GetIssues(ctx context.Context, keys []string) (map[string]*scm.Issue, error)
+}
+
+// IssueErrors the errors looking up issues indexed by issue key
+type IssueErrors map[string]error
+
+func (e IssueErrors) Error() string {
+	var keys []string
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var messages []string
+	for _, k := range keys {
+		messages = append(messages, fmt.Sprintf("%s: %s", k, e[k]))
+	}
+	return fmt.Sprintf("failed to look up issues %s", strings.Join(messages, "; "))
+}
+
+// AsBatchIssueProvider returns the tracker if it is a BatchIssueProvider otherwise an adapter which looks up the
+// issues one at a time
+func AsBatchIssueProvider(tracker IssueProvider) BatchIssueProvider {
+	if b, ok := tracker.(BatchIssueProvider); ok {
+		return b
+	}
+	return &issueProviderAdapter{tracker}
+}
+
+// LooksUpBatches returns true if the tracker looks up several issues with one request. Other trackers look up
+// issues one at a time so are better looked up concurrently
+func LooksUpBatches(tracker IssueProvider) bool {
+	switch t := tracker.(type) {
+	case *JiraService:
+		return true
+	case *GitIssueProvider:
+		return t.usesGraphQL()
+	default:
+		return false
+	}
+}
+
+// issueProviderAdapter adapts an IssueProvider without context support to a BatchIssueProvider
+type issueProviderAdapter struct {
+	IssueProvider
+}
+
+type issueResult struct {
+	issue *scm.Issue
+	err   error
+}
+
+// GetIssueWithContext looks up the issue in the background so that the deadline of the context is honoured even
+// though the issue provider does not support contexts
+func (a *issueProviderAdapter) GetIssueWithContext(ctx context.Context, key string) (*scm.Issue, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ch := make(chan issueResult, 1)
+	go func() {
+		issue, err := a.GetIssue(key)
+		ch <- issueResult{issue, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.issue, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (a *issueProviderAdapter) GetIssues(ctx context.Context, keys []string) (map[string]*scm.Issue, error) {
+	return getIssuesOneByOne(ctx, keys, a.GetIssueWithContext)
+}
+
+// getIssuesOneByOne looks up the issues one at a time. Stops at the deadline of the context
+func getIssuesOneByOne(ctx context.Context, keys []string, getIssue func(context.Context, string) (*scm.Issue, error)) (map[string]*scm.Issue, error) {
+	answer := map[string]*scm.Issue{}
+	failed := IssueErrors{}
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			failed[key] = err
+			continue
+		}
+		issue, err := getIssue(ctx, key)
+		if err != nil {
+			failed[key] = err
+			continue
+		}
+		if issue != nil {
+			answer[key] = issue
+		}
+	}
+	if len(failed) > 0 {
+		return answer, failed
+	}
+	return answer, nil
+}
+
+// chunkKeys splits the keys into batches of at most size keys
+func chunkKeys(keys []string, size int) [][]string {
+	var answer [][]string
+	for len(keys) > size {
+		answer = append(answer, keys[:size])
+		keys = keys[size:]
+	}
+	if len(keys) > 0 {
+		answer = append(answer, keys)
+	}
+	return answer
+}
@@ -0,0 +1,62 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowIssueProvider an issue provider without context support
+type slowIssueProvider struct {
+	issues.IssueProvider
+	delay time.Duration
+}
+
+func (p *slowIssueProvider) GetIssue(key string) (*scm.Issue, error) {
+	time.Sleep(p.delay)
+	switch key {
+	case "1":
+		return &scm.Issue{Number: 1, Title: "the login page is broken"}, nil
+	case "2":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("issue %s is broken", key)
+	}
+}
+
+func TestAsBatchIssueProvider(t *testing.T) {
+	tracker := issues.AsBatchIssueProvider(&slowIssueProvider{})
+
+	found, err := tracker.GetIssues(context.Background(), []string{"1", "2", "3"})
+	require.Len(t, found, 1)
+	assert.Equal(t, "the login page is broken", found["1"].Title)
+
+	var errs issues.IssueErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs["3"], "issue 3 is broken")
+}
+
+func TestAsBatchIssueProviderDeadline(t *testing.T) {
+	tracker := issues.AsBatchIssueProvider(&slowIssueProvider{delay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	found, err := tracker.GetIssues(ctx, []string{"1", "2"})
+	assert.Less(t, time.Since(start), time.Second, "the deadline is honoured by the adapter")
+	assert.Empty(t, found)
+
+	var errs issues.IssueErrors
+	require.ErrorAs(t, err, &errs)
+	assert.ErrorIs(t, errs["1"], context.DeadlineExceeded)
+	assert.ErrorIs(t, errs["2"], context.DeadlineExceeded)
+}
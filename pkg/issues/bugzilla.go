@@ -0,0 +1,262 @@
+package issues
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+// BugzillaService an issue provider using the Bugzilla REST API
+type BugzillaService struct {
+	HTTPClient *http.Client
+	ServerURL  string
+	APIKey     string
+	Product    string
+	Component  string
+}
+
+type bugzillaUser struct {
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Email    string `json:"email"`
+}
+
+type bugzillaBug struct {
+	ID             int           `json:"id"`
+	Summary        string        `json:"summary"`
+	Status         string        `json:"status"`
+	Resolution     string        `json:"resolution"`
+	IsOpen         bool          `json:"is_open"`
+	Product        string        `json:"product"`
+	Component      string        `json:"component"`
+	Keywords       []string      `json:"keywords"`
+	CreationTime   time.Time     `json:"creation_time"`
+	LastChangeTime time.Time     `json:"last_change_time"`
+	CreatorDetail  *bugzillaUser `json:"creator_detail"`
+	AssignedDetail *bugzillaUser `json:"assigned_to_detail"`
+}
+
+type bugzillaBugs struct {
+	Bugs []bugzillaBug `json:"bugs"`
+}
+
+type bugzillaComments struct {
+	Bugs map[string]struct {
+		Comments []struct {
+			Text string `json:"text"`
+		} `json:"comments"`
+	} `json:"bugs"`
+}
+
+// CreateBugzillaIssueProvider creates an issue provider for the product on the Bugzilla server. The API key is
+// optional for public servers
+func CreateBugzillaIssueProvider(serverURL, apiKey, product, component string) (IssueProvider, error) {
+	if serverURL == "" {
+		return nil, fmt.Errorf("no Bugzilla server URL")
+	}
+	return &BugzillaService{
+		HTTPClient: newHTTPClient(),
+		ServerURL:  strings.TrimSuffix(serverURL, "/"),
+		APIKey:     apiKey,
+		Product:    product,
+		Component:  component,
+	}, nil
+}
+
+func (i *BugzillaService) GetIssue(key string) (*scm.Issue, error) {
+	id, err := issueKeyToNumber(key)
+	if err != nil {
+		return nil, err
+	}
+	result := &bugzillaBugs{}
+	err = i.do(http.MethodGet, "rest/bug/"+strconv.Itoa(id), nil, nil, result)
+	if isBugNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find bug %d: %w", id, err)
+	}
+	if len(result.Bugs) == 0 {
+		return nil, nil
+	}
+	return i.bugzillaToGitIssue(&result.Bugs[0]), nil
+}
+
+func (i *BugzillaService) SearchIssues(query string) ([]*scm.Issue, error) {
+	params := url.Values{}
+	if i.Product != "" {
+		params.Set("product", i.Product)
+	}
+	params.Set("resolution", "---")
+	if query != "" {
+		params.Set("quicksearch", query)
+	}
+	return i.search(params, false)
+}
+
+func (i *BugzillaService) SearchIssuesClosedSince(t time.Time) ([]*scm.Issue, error) {
+	params := url.Values{}
+	if i.Product != "" {
+		params.Set("product", i.Product)
+	}
+	params.Set("last_change_time", t.UTC().Format(time.RFC3339))
+	return i.search(params, true)
+}
+
+func (i *BugzillaService) search(params url.Values, closedOnly bool) ([]*scm.Issue, error) {
+	result := &bugzillaBugs{}
+	err := i.do(http.MethodGet, "rest/bug", params, nil, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search bugs: %w", err)
+	}
+	var answer []*scm.Issue
+	for k := range result.Bugs {
+		bug := &result.Bugs[k]
+		if closedOnly && bug.IsOpen {
+			continue
+		}
+		answer = append(answer, i.bugzillaToGitIssue(bug))
+	}
+	return answer, nil
+}
+
+func (i *BugzillaService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	body := map[string]interface{}{
+		"product":     i.Product,
+		"component":   i.Component,
+		"summary":     issue.Title,
+		"description": issue.Body,
+		"version":     "unspecified",
+	}
+	result := &struct {
+		ID int `json:"id"`
+	}{}
+	err := i.do(http.MethodPost, "rest/bug", nil, body, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bug: %w", err)
+	}
+	return i.GetIssue(strconv.Itoa(result.ID))
+}
+
+func (i *BugzillaService) CreateIssueComment(key, comment string) error {
+	id, err := issueKeyToNumber(key)
+	if err != nil {
+		return err
+	}
+	err = i.do(http.MethodPost, "rest/bug/"+strconv.Itoa(id)+"/comment", nil, map[string]string{"comment": comment}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add comment to bug %d: %w", id, err)
+	}
+	return nil
+}
+
+func (i *BugzillaService) GetIssueComments(key string) ([]string, error) {
+	id, err := issueKeyToNumber(key)
+	if err != nil {
+		return nil, err
+	}
+	result := &bugzillaComments{}
+	err = i.do(http.MethodGet, "rest/bug/"+strconv.Itoa(id)+"/comment", nil, nil, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments of bug %d: %w", id, err)
+	}
+	var answer []string
+	for _, c := range result.Bugs[strconv.Itoa(id)].Comments {
+		answer = append(answer, c.Text)
+	}
+	return answer, nil
+}
+
+func (i *BugzillaService) IssueURL(key string) string {
+	return i.ServerURL + "/show_bug.cgi?id=" + url.QueryEscape(key)
+}
+
+func (i *BugzillaService) HomeURL() string {
+	if i.Product == "" {
+		return i.ServerURL
+	}
+	return i.ServerURL + "/describecomponents.cgi?product=" + url.QueryEscape(i.Product)
+}
+
+func (i *BugzillaService) bugzillaToGitIssue(bug *bugzillaBug) *scm.Issue {
+	key := strconv.Itoa(bug.ID)
+	answer := &scm.Issue{
+		Number:  bug.ID,
+		Title:   bug.Summary,
+		Link:    i.IssueURL(key),
+		State:   IssueStateOpen,
+		Created: bug.CreationTime,
+		Updated: bug.LastChangeTime,
+	}
+	if !bug.IsOpen {
+		answer.State = IssueStateClosed
+		answer.Closed = true
+	}
+	answer.Labels = append(answer.Labels, bug.Keywords...)
+	for _, field := range [][2]string{
+		{BugzillaProductLabelPrefix, bug.Product},
+		{BugzillaComponentLabelPrefix, bug.Component},
+		{BugzillaStatusLabelPrefix, bug.Status},
+		{BugzillaResolutionLabelPrefix, bug.Resolution},
+	} {
+		if field[1] != "" {
+			answer.Labels = append(answer.Labels, field[0]+field[1])
+		}
+	}
+	if user := bugzillaUserToGitUser(bug.CreatorDetail); user != nil {
+		answer.Author = *user
+	}
+	if user := bugzillaUserToGitUser(bug.AssignedDetail); user != nil {
+		answer.Assignees = []scm.User{*user}
+	}
+	return answer
+}
+
+func bugzillaUserToGitUser(user *bugzillaUser) *scm.User {
+	if user == nil {
+		return nil
+	}
+	return &scm.User{
+		Login: user.Name,
+		Name:  user.RealName,
+		Email: user.Email,
+	}
+}
+
+// do invokes the REST API and decodes the JSON response into result if it is not nil
+// bugzillaErrorCodeNotFound the code of the error of bugs which do not exist
+const bugzillaErrorCodeNotFound = 101
+
+// isBugNotFound returns true if the error is the response of a bug which does not exist
+func isBugNotFound(err error) bool {
+	status, body := errorStatus(err)
+	if status == http.StatusNotFound {
+		return true
+	}
+	if status == 0 {
+		return false
+	}
+	result := &struct {
+		Code int `json:"code"`
+	}{}
+	return json.Unmarshal(body, result) == nil && result.Code == bugzillaErrorCodeNotFound
+}
+
+func (i *BugzillaService) do(method, path string, params url.Values, body, result interface{}) error {
+	u := stringhelpers.UrlJoin(i.ServerURL, path)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	header := http.Header{}
+	if i.APIKey != "" {
+		header.Set("X-BUGZILLA-API-KEY", i.APIKey)
+	}
+	return doJSON(i.HTTPClient, method, u, header, body, result)
+}
@@ -0,0 +1,130 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bugzillaBugJSON = `{
+  "bugs": [{
+    "id": 12345,
+    "summary": "the login page is broken",
+    "status": "RESOLVED",
+    "resolution": "FIXED",
+    "is_open": false,
+    "product": "Example",
+    "component": "frontend",
+    "keywords": ["Regression"],
+    "creation_time": "2021-01-02T10:00:00Z",
+    "last_change_time": "2021-01-04T10:00:00Z",
+    "creator_detail": {"name": "jstrachan@example.com", "real_name": "James Strachan", "email": "jstrachan@example.com"},
+    "assigned_to_detail": {"name": "rawlingsj@example.com", "real_name": "James Rawlings", "email": "rawlingsj@example.com"}
+  }]
+}`
+
+func TestBugzillaGetIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/bug/12345", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-BUGZILLA-API-KEY"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(bugzillaBugJSON))
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateBugzillaIssueProvider(server.URL+"/", "secret", "Example", "")
+	require.NoError(t, err)
+	assert.NotZero(t, tracker.(*issues.BugzillaService).HTTPClient.Timeout, "requests time out")
+	assert.Equal(t, issues.Bugzilla, issues.GetIssueProvider(tracker))
+
+	issue, err := tracker.GetIssue("12345")
+	require.NoError(t, err)
+
+	assert.Equal(t, 12345, issue.Number)
+	assert.Equal(t, "the login page is broken", issue.Title)
+	assert.Equal(t, server.URL+"/show_bug.cgi?id=12345", issue.Link)
+	assert.Equal(t, issues.IssueStateClosed, issue.State)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, []string{"Regression", "product/Example", "component/frontend", "status/RESOLVED", "resolution/FIXED"}, issue.Labels)
+	assert.Equal(t, time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC), issue.Created.UTC())
+	assert.Equal(t, "James Strachan", issue.Author.Name)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "rawlingsj@example.com", issue.Assignees[0].Email)
+
+	_, err = tracker.GetIssue("ABC-1")
+	assert.Error(t, err)
+}
+
+func TestBugzillaGetMissingIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/rest/bug/404":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": true, "code": 101, "message": "Bug #404 does not exist."}`))
+		case "/rest/bug/101":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": true, "code": 101, "message": "Bug #101 does not exist."}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error": true, "code": 32000, "message": "database unavailable"}`))
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateBugzillaIssueProvider(server.URL, "", "", "")
+	require.NoError(t, err)
+
+	for _, key := range []string{"404", "101"} {
+		issue, err := tracker.GetIssue(key)
+		require.NoError(t, err, "bug %s", key)
+		assert.Nil(t, issue, "bugs which do not exist are not found rather than failed lookups")
+	}
+
+	_, err = tracker.GetIssue("500")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database unavailable")
+}
+
+func TestBugzillaComments(t *testing.T) {
+	var added []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /rest/bug/12345/comment":
+			_, _ = w.Write([]byte(`{"bugs": {"12345": {"comments": [{"text": "description"}, {"text": "first"}]}}}`))
+		case "POST /rest/bug/12345/comment":
+			body := map[string]string{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			added = append(added, body["comment"])
+			_, _ = w.Write([]byte(`{"id": 3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": true, "code": 101, "message": "Bug #999 does not exist."}`))
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateBugzillaIssueProvider(server.URL, "", "", "")
+	require.NoError(t, err)
+
+	comments, err := tracker.(issues.IssueCommentLister).GetIssueComments("12345")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"description", "first"}, comments)
+
+	err = tracker.CreateIssueComment("12345", "Released in version 1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Released in version 1.2.0"}, added)
+
+	err = tracker.CreateIssueComment("999", "hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Bug #999 does not exist.")
+}
@@ -0,0 +1,298 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// PatternTracker an issue tracker together with the regular expression of the references to its issues in commit
+// messages. If the expression has a group the first group is the issue key otherwise the whole match without any
+// leading #
+type PatternTracker struct {
+	Tracker IssueProvider
+	Regexp  *regexp.Regexp
+}
+
+// CompositeIssueProvider combines several issue trackers such as Jira for ABC-123 and the git provider for #123.
+//
+// The ID of an issue is its key prefixed with the prefix of its tracker such as bug-123 for Bugzilla and AB#123 for
+// Azure DevOps so that the numeric keys of these trackers do not collide with the ones of the git provider. Use
+// IssueID to get the ID of the key of an issue of a tracker. Calls for an issue are sent to the tracker which found
+// it with GetIssueFrom. Other IDs go to the tracker whose prefix they have or otherwise the first tracker whose
+// regular expression matches the ID or the ID prefixed with #.
+type CompositeIssueProvider struct {
+	Trackers []*PatternTracker
+
+	lock   sync.RWMutex
+	owners map[string]IssueProvider
+}
+
+// NewCompositeIssueProvider creates an issue provider combining the trackers. The first tracker is used to create
+// issues and as home URL
+func NewCompositeIssueProvider(trackers ...*PatternTracker) *CompositeIssueProvider {
+	return &CompositeIssueProvider{
+		Trackers: trackers,
+		owners:   map[string]IssueProvider{},
+	}
+}
+
+// Providers returns the trackers of a composite issue provider or the tracker itself if it is not a composite
+func Providers(tracker IssueProvider) []IssueProvider {
+	c, ok := tracker.(*CompositeIssueProvider)
+	if !ok {
+		if tracker == nil {
+			return nil
+		}
+		return []IssueProvider{tracker}
+	}
+	var answer []IssueProvider
+	for _, t := range c.Trackers {
+		answer = append(answer, t.Tracker)
+	}
+	return answer
+}
+
+// keyPrefix returns the prefix of the IDs of the issues of the tracker. Only trackers with numeric keys have a prefix
+func keyPrefix(tracker IssueProvider) string {
+	switch tracker.(type) {
+	case *BugzillaService:
+		return "bug-"
+	case *AzureService:
+		return "AB#"
+	default:
+		return ""
+	}
+}
+
+// IssueID returns the ID of the issue of the key of the tracker
+func (c *CompositeIssueProvider) IssueID(tracker IssueProvider, key string) string {
+	prefix := keyPrefix(tracker)
+	if strings.HasPrefix(key, prefix) {
+		return key
+	}
+	return prefix + key
+}
+
+// GetIssueFrom returns the issue of the key from the tracker and remembers the tracker so that later calls for the
+// issue are sent to it
+func (c *CompositeIssueProvider) GetIssueFrom(tracker IssueProvider, key string) (*scm.Issue, error) {
+	issue, err := tracker.GetIssue(key)
+	if err != nil || issue == nil {
+		return issue, err
+	}
+	c.SetOwner(key, tracker)
+	return issue, nil
+}
+
+// GetIssuesFrom returns the issues of the keys from the tracker indexed by key and remembers the tracker of the
+// issues which were found so that later calls for the issues are sent to it
+func (c *CompositeIssueProvider) GetIssuesFrom(ctx context.Context, tracker IssueProvider, keys []string) (map[string]*scm.Issue, error) {
+	found, err := AsBatchIssueProvider(tracker).GetIssues(ctx, keys)
+	for key := range found {
+		c.SetOwner(key, tracker)
+	}
+	return found, err
+}
+
+// SetOwner remembers the tracker of the issue key such as when the issue was found in a cache
+func (c *CompositeIssueProvider) SetOwner(key string, tracker IssueProvider) {
+	id := c.IssueID(tracker, key)
+	c.lock.Lock()
+	c.owners[id] = tracker
+	c.lock.Unlock()
+}
+
+// trackerOf returns the tracker of the issue ID together with the key of the issue in the tracker
+func (c *CompositeIssueProvider) trackerOf(id string) (IssueProvider, string, error) {
+	c.lock.RLock()
+	owner := c.owners[id]
+	c.lock.RUnlock()
+	if owner != nil {
+		return owner, strings.TrimPrefix(id, keyPrefix(owner)), nil
+	}
+	for _, t := range c.Trackers {
+		prefix := keyPrefix(t.Tracker)
+		if prefix != "" && strings.HasPrefix(id, prefix) {
+			return t.Tracker, strings.TrimPrefix(id, prefix), nil
+		}
+	}
+	for _, t := range c.Trackers {
+		if t.Regexp != nil && (t.Regexp.MatchString(id) || t.Regexp.MatchString("#"+id)) {
+			return t.Tracker, id, nil
+		}
+	}
+	return nil, "", fmt.Errorf("no issue tracker found for issue %s", id)
+}
+
+func (c *CompositeIssueProvider) GetIssue(id string) (*scm.Issue, error) {
+	tracker, key, err := c.trackerOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetIssueFrom(tracker, key)
+}
+
+func (c *CompositeIssueProvider) GetIssueWithContext(ctx context.Context, id string) (*scm.Issue, error) {
+	tracker, key, err := c.trackerOf(id)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := AsBatchIssueProvider(tracker).GetIssueWithContext(ctx, key)
+	if err != nil || issue == nil {
+		return issue, err
+	}
+	c.SetOwner(key, tracker)
+	return issue, nil
+}
+
+// GetIssues groups the IDs by tracker and looks up the issues of each tracker in batches
+func (c *CompositeIssueProvider) GetIssues(ctx context.Context, ids []string) (map[string]*scm.Issue, error) {
+	answer := map[string]*scm.Issue{}
+	failed := IssueErrors{}
+	var trackers []IssueProvider
+	keysByTracker := map[IssueProvider][]string{}
+	for _, id := range ids {
+		tracker, key, err := c.trackerOf(id)
+		if err != nil {
+			failed[id] = err
+			continue
+		}
+		if keysByTracker[tracker] == nil {
+			trackers = append(trackers, tracker)
+		}
+		keysByTracker[tracker] = append(keysByTracker[tracker], key)
+	}
+	for _, tracker := range trackers {
+		found, err := c.GetIssuesFrom(ctx, tracker, keysByTracker[tracker])
+		for k, v := range found {
+			answer[c.IssueID(tracker, k)] = v
+		}
+		if err == nil {
+			continue
+		}
+		var errs IssueErrors
+		if errors.As(err, &errs) {
+			for k, v := range errs {
+				failed[c.IssueID(tracker, k)] = v
+			}
+			continue
+		}
+		for _, key := range keysByTracker[tracker] {
+			id := c.IssueID(tracker, key)
+			if answer[id] == nil {
+				failed[id] = err
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return answer, failed
+	}
+	return answer, nil
+}
+
+func (c *CompositeIssueProvider) SearchIssues(query string) ([]*scm.Issue, error) {
+	var answer []*scm.Issue
+	for _, t := range c.Trackers {
+		found, err := t.Tracker.SearchIssues(query)
+		if err != nil {
+			return answer, fmt.Errorf("failed to search issues in %s: %w", t.Tracker.HomeURL(), err)
+		}
+		answer = append(answer, found...)
+	}
+	return answer, nil
+}
+
+func (c *CompositeIssueProvider) SearchIssuesClosedSince(since time.Time) ([]*scm.Issue, error) {
+	var answer []*scm.Issue
+	for _, t := range c.Trackers {
+		found, err := t.Tracker.SearchIssuesClosedSince(since)
+		if err != nil {
+			return answer, fmt.Errorf("failed to search issues in %s: %w", t.Tracker.HomeURL(), err)
+		}
+		answer = append(answer, found...)
+	}
+	return answer, nil
+}
+
+func (c *CompositeIssueProvider) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	if len(c.Trackers) == 0 {
+		return nil, fmt.Errorf("no issue trackers")
+	}
+	return c.Trackers[0].Tracker.CreateIssue(issue)
+}
+
+func (c *CompositeIssueProvider) CreateIssueComment(id, comment string) error {
+	tracker, key, err := c.trackerOf(id)
+	if err != nil {
+		return err
+	}
+	return tracker.CreateIssueComment(key, comment)
+}
+
+func (c *CompositeIssueProvider) GetIssueComments(id string) ([]string, error) {
+	tracker, key, err := c.trackerOf(id)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := tracker.(IssueCommentLister)
+	if !ok {
+		return nil, ErrCommentsNotSupported
+	}
+	return lister.GetIssueComments(key)
+}
+
+func (c *CompositeIssueProvider) IssueURL(id string) string {
+	tracker, key, err := c.trackerOf(id)
+	if err != nil {
+		return ""
+	}
+	return tracker.IssueURL(key)
+}
+
+func (c *CompositeIssueProvider) HomeURL() string {
+	if len(c.Trackers) == 0 {
+		return ""
+	}
+	return c.Trackers[0].Tracker.HomeURL()
+}
+
+// SetFixVersion sets the fix version of the issues of the trackers which support fix versions. Issues of other
+// trackers are ignored
+func (c *CompositeIssueProvider) SetFixVersion(version string, ids []string, release bool) error {
+	var trackers []IssueProvider
+	keysByTracker := map[IssueProvider][]string{}
+	for _, id := range ids {
+		tracker, key, err := c.trackerOf(id)
+		if err != nil {
+			continue
+		}
+		if _, ok := tracker.(FixVersionTracker); !ok {
+			continue
+		}
+		if keysByTracker[tracker] == nil {
+			trackers = append(trackers, tracker)
+		}
+		keysByTracker[tracker] = append(keysByTracker[tracker], key)
+	}
+	if len(trackers) == 0 && len(ids) > 0 {
+		return fmt.Errorf("none of the issue trackers support fix versions")
+	}
+	var failed []string
+	for _, tracker := range trackers {
+		err := tracker.(FixVersionTracker).SetFixVersion(version, keysByTracker[tracker], release)
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to set fix versions: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
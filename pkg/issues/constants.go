@@ -1,8 +1,51 @@
 package issues
 
 const (
+	Azure    = "azure"
 	Bugzilla = "bugzilla"
+	External = "external"
 	Jira     = "jira"
+	Linear   = "linear"
 	Trello   = "trello"
 	Git      = "git"
 )
+
+const (
+	// IssueStateOpen the state of issues which are not resolved
+	IssueStateOpen = "open"
+	// IssueStateClosed the state of resolved issues
+	IssueStateClosed = "closed"
+)
+
+// The prefixes of the labels added to Jira issues for their fields
+const (
+	JiraTypeLabelPrefix           = "type/"
+	JiraStatusLabelPrefix         = "status/"
+	JiraResolutionLabelPrefix     = "resolution/"
+	JiraResolutionDateLabelPrefix = "resolved/"
+	JiraComponentLabelPrefix      = "component/"
+	JiraFixVersionLabelPrefix     = "fixVersion/"
+)
+
+// JiraResolutionDateFormat the format of the date of the resolution date label of Jira issues
+const JiraResolutionDateFormat = "2006-01-02"
+
+// The modes of authenticating with Jira
+const (
+	// JiraAuthBasic basic authentication with the user name and an API token as used by Jira Cloud
+	JiraAuthBasic = "basic"
+	// JiraAuthBearer bearer authentication with a personal access token as used by Jira Server and Data Center or
+	// an OAuth 2.0 access token
+	JiraAuthBearer = "bearer"
+)
+
+// The prefixes of the labels added to Bugzilla bugs for their fields
+const (
+	BugzillaProductLabelPrefix    = "product/"
+	BugzillaComponentLabelPrefix  = "component/"
+	BugzillaStatusLabelPrefix     = "status/"
+	BugzillaResolutionLabelPrefix = "resolution/"
+)
+
+// AzureTypeLabelPrefix the prefix of the label added to Azure DevOps work items for their type such as type/Bug
+const AzureTypeLabelPrefix = "type/"
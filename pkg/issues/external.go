@@ -0,0 +1,403 @@
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// The operations of the external issue provider protocol
+const (
+	ExternalGetIssue                = "getIssue"
+	ExternalSearchIssues            = "searchIssues"
+	ExternalSearchIssuesClosedSince = "searchIssuesClosedSince"
+	ExternalCreateIssue             = "createIssue"
+	ExternalComment                 = "comment"
+	ExternalGetComments             = "getComments"
+	ExternalHomeURL                 = "homeURL"
+)
+
+// ExternalRequest the JSON request an external issue provider receives on stdin
+type ExternalRequest struct {
+	// Operation the operation such as getIssue
+	Operation string `json:"operation"`
+
+	// Key the key of the issue for the getIssue, comment and getComments operations
+	Key string `json:"key,omitempty"`
+
+	// Comment the comment to add for the comment operation
+	Comment string `json:"comment,omitempty"`
+
+	// Query the text to search for in the searchIssues operation
+	Query string `json:"query,omitempty"`
+
+	// Since the time since which closed issues are returned by the searchIssuesClosedSince operation
+	Since *time.Time `json:"since,omitempty"`
+
+	// Issue the issue to create for the createIssue operation
+	Issue *ExternalIssue `json:"issue,omitempty"`
+}
+
+// ExternalResponse the JSON response an external issue provider writes to stdout
+type ExternalResponse struct {
+	// Error the error message if the operation failed
+	Error string `json:"error,omitempty"`
+
+	// Issue the issue returned by the getIssue and createIssue operations. Null if the issue does not exist
+	Issue *ExternalIssue `json:"issue,omitempty"`
+
+	// Issues the issues returned by the search operations
+	Issues []ExternalIssue `json:"issues,omitempty"`
+
+	// Comments the bodies of the comments returned by the getComments operation
+	Comments []string `json:"comments,omitempty"`
+
+	// URL the URL returned by the homeURL operation
+	URL string `json:"url,omitempty"`
+}
+
+// ExternalIssue an issue of an external issue provider
+type ExternalIssue struct {
+	Key       string         `json:"key,omitempty"`
+	Number    int            `json:"number,omitempty"`
+	Title     string         `json:"title,omitempty"`
+	Body      string         `json:"body,omitempty"`
+	URL       string         `json:"url,omitempty"`
+	State     string         `json:"state,omitempty"`
+	Closed    bool           `json:"closed,omitempty"`
+	Labels    []string       `json:"labels,omitempty"`
+	Author    *ExternalUser  `json:"author,omitempty"`
+	Assignees []ExternalUser `json:"assignees,omitempty"`
+	Created   *time.Time     `json:"created,omitempty"`
+	Updated   *time.Time     `json:"updated,omitempty"`
+}
+
+// ExternalUser a user of an external issue provider
+type ExternalUser struct {
+	Login     string `json:"login,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Email     string `json:"email,omitempty"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// externalEnvironment the environment variables always passed to external commands
+var externalEnvironment = []string{"PATH", "HOME", "TMPDIR", "LANG", "LC_ALL", "TZ"}
+
+// ExternalService an issue provider delegating to an external command in the same way as git credential helpers.
+//
+// The command is run for each operation with an ExternalRequest as JSON on stdin and must write an
+// ExternalResponse as JSON to stdout. Anything written to stderr is only used in error messages. The command only
+// gets a minimal environment together with the variables listed in Env so that the secrets of the pipeline such
+// as the git token are not passed to it.
+//
+// The home URL is only requested once. The URLs of issues are the ones of the issues returned by the command or
+// otherwise built from IssueURLTemplate so that the command is not run for each link.
+type ExternalService struct {
+	// CommandRunner runs the command. Defaults to running it with only the environment of the command
+	CommandRunner cmdrunner.CommandRunner
+
+	// Name the name of the issue tracker used in messages
+	Name string
+
+	// Command the command and its arguments
+	Command string
+	Args    []string
+
+	// Env the names of the environment variables passed to the command in addition to PATH, HOME, TMPDIR, LANG,
+	// LC_ALL and TZ such as the token of the issue tracker
+	Env []string
+
+	// Dir the directory the command is run in
+	Dir string
+
+	// IssueURLTemplate the go template of the URL of an issue such as https://tickets.example.com/{{ .Key }}
+	IssueURLTemplate string
+
+	// Regexp the regular expression of the references to issues in commit messages. If it has a group the first
+	// group is the issue key
+	Regexp *regexp.Regexp
+
+	issueURLTemplate *template.Template
+	homeOnce         sync.Once
+	homeURL          string
+	lock             sync.Mutex
+	issueURLs        map[string]string
+}
+
+// externalIssueURLData the data of the template of the URL of an issue
+type externalIssueURLData struct {
+	Key string
+}
+
+// NewExternalIssueProvider creates an issue provider running the command for each operation. The optional issueURL
+// is the go template of the URL of an issue
+func NewExternalIssueProvider(name, dir, command, issueURL string, args, env []string, regex *regexp.Regexp) (IssueProvider, error) {
+	if command == "" {
+		return nil, fmt.Errorf("no command for external issue tracker %s", name)
+	}
+	if regex == nil {
+		return nil, fmt.Errorf("no pattern for external issue tracker %s", name)
+	}
+	if name == "" {
+		name = command
+	}
+	var issueURLTemplate *template.Template
+	if issueURL != "" {
+		var err error
+		issueURLTemplate, err = template.New("issueURL").Option("missingkey=error").Parse(issueURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue URL template of external issue tracker %s: %w", name, err)
+		}
+	}
+	return &ExternalService{
+		Name:             name,
+		Command:          command,
+		Args:             args,
+		Env:              env,
+		Dir:              dir,
+		IssueURLTemplate: issueURL,
+		Regexp:           regex,
+		issueURLTemplate: issueURLTemplate,
+	}, nil
+}
+
+func (i *ExternalService) GetIssue(key string) (*scm.Issue, error) {
+	resp, err := i.invoke(&ExternalRequest{Operation: ExternalGetIssue, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Issue == nil {
+		return nil, nil
+	}
+	i.rememberIssueURL(key, resp.Issue.URL)
+	return i.toGitIssue(resp.Issue), nil
+}
+
+func (i *ExternalService) SearchIssues(query string) ([]*scm.Issue, error) {
+	resp, err := i.invoke(&ExternalRequest{Operation: ExternalSearchIssues, Query: query})
+	if err != nil {
+		return nil, err
+	}
+	return i.toGitIssues(resp.Issues), nil
+}
+
+func (i *ExternalService) SearchIssuesClosedSince(t time.Time) ([]*scm.Issue, error) {
+	resp, err := i.invoke(&ExternalRequest{Operation: ExternalSearchIssuesClosedSince, Since: &t})
+	if err != nil {
+		return nil, err
+	}
+	return i.toGitIssues(resp.Issues), nil
+}
+
+func (i *ExternalService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	resp, err := i.invoke(&ExternalRequest{
+		Operation: ExternalCreateIssue,
+		Issue: &ExternalIssue{
+			Title:  issue.Title,
+			Body:   issue.Body,
+			Labels: issue.Labels,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Issue == nil {
+		return nil, fmt.Errorf("external issue tracker %s did not return the created issue", i.Name)
+	}
+	return i.toGitIssue(resp.Issue), nil
+}
+
+func (i *ExternalService) CreateIssueComment(key, comment string) error {
+	_, err := i.invoke(&ExternalRequest{Operation: ExternalComment, Key: key, Comment: comment})
+	return err
+}
+
+func (i *ExternalService) GetIssueComments(key string) ([]string, error) {
+	resp, err := i.invoke(&ExternalRequest{Operation: ExternalGetComments, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Comments, nil
+}
+
+// IssueURL returns the URL of the issue returned by the command or otherwise the one of the IssueURLTemplate
+func (i *ExternalService) IssueURL(key string) string {
+	i.lock.Lock()
+	link := i.issueURLs[key]
+	i.lock.Unlock()
+	if link != "" || i.issueURLTemplate == nil {
+		return link
+	}
+	var buf bytes.Buffer
+	err := i.issueURLTemplate.Execute(&buf, &externalIssueURLData{Key: key})
+	if err != nil {
+		log.Logger().Warnf("failed to create the URL of issue %s of external issue tracker %s: %s", key, i.Name, err)
+		return ""
+	}
+	return buf.String()
+}
+
+// HomeURL returns the URL returned by the command the first time it is called or otherwise the name
+func (i *ExternalService) HomeURL() string {
+	i.homeOnce.Do(func() {
+		i.homeURL = i.Name
+		resp, err := i.invoke(&ExternalRequest{Operation: ExternalHomeURL})
+		if err != nil {
+			log.Logger().Debugf("%s", err)
+			return
+		}
+		if resp.URL != "" {
+			i.homeURL = resp.URL
+		}
+	})
+	return i.homeURL
+}
+
+// rememberIssueURL remembers the URL of the issue returned by the command
+func (i *ExternalService) rememberIssueURL(key, link string) {
+	if key == "" || link == "" {
+		return
+	}
+	i.lock.Lock()
+	if i.issueURLs == nil {
+		i.issueURLs = map[string]string{}
+	}
+	i.issueURLs[key] = link
+	i.lock.Unlock()
+}
+
+// invoke runs the command with the request on stdin and parses the response from stdout
+func (i *ExternalService) invoke(req *ExternalRequest) (*ExternalResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	c := &cmdrunner.Command{
+		Dir:  i.Dir,
+		Name: i.Command,
+		Args: i.Args,
+		Env:  i.environment(),
+		In:   bytes.NewReader(data),
+		Out:  &stdout,
+		Err:  &stderr,
+	}
+	runner := i.CommandRunner
+	if runner == nil {
+		runner = runWithEnvironment
+	}
+	_, err = runner(c)
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message != "" {
+			return nil, fmt.Errorf("external issue tracker %s failed to %s: %s: %w", i.Name, req.Operation, message, err)
+		}
+		return nil, fmt.Errorf("external issue tracker %s failed to %s: %w", i.Name, req.Operation, err)
+	}
+	resp := &ExternalResponse{}
+	if strings.TrimSpace(stdout.String()) != "" {
+		err = json.Unmarshal(stdout.Bytes(), resp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the response of external issue tracker %s to %s: %w", i.Name, req.Operation, err)
+		}
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("external issue tracker %s failed to %s: %s", i.Name, req.Operation, resp.Error)
+	}
+	return resp, nil
+}
+
+// environment returns the environment variables passed to the command which are set
+func (i *ExternalService) environment() map[string]string {
+	answer := map[string]string{}
+	for _, names := range [][]string{externalEnvironment, i.Env} {
+		for _, name := range names {
+			if value, ok := os.LookupEnv(name); ok {
+				answer[name] = value
+			}
+		}
+	}
+	return answer
+}
+
+// runWithEnvironment runs the command with only the environment variables of the command rather than adding them
+// to the ones of this process like cmdrunner does
+func runWithEnvironment(c *cmdrunner.Command) (string, error) {
+	cmd := exec.Command(c.Name, c.Args...) //nolint:gosec // external commands have to be allowed explicitly
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.In
+	cmd.Stdout = c.Out
+	cmd.Stderr = c.Err
+	cmd.Env = []string{}
+	for k, v := range c.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	return "", cmd.Run()
+}
+
+func (i *ExternalService) toGitIssues(issues []ExternalIssue) []*scm.Issue {
+	var answer []*scm.Issue
+	for k := range issues {
+		answer = append(answer, i.toGitIssue(&issues[k]))
+	}
+	return answer
+}
+
+// toGitIssue converts the issue and remembers its URL
+func (i *ExternalService) toGitIssue(issue *ExternalIssue) *scm.Issue {
+	i.rememberIssueURL(issue.Key, issue.URL)
+	return externalToGitIssue(issue)
+}
+
+func externalToGitIssue(issue *ExternalIssue) *scm.Issue {
+	answer := &scm.Issue{
+		Number: issue.Number,
+		Title:  issue.Title,
+		Body:   issue.Body,
+		Link:   issue.URL,
+		State:  issue.State,
+		Closed: issue.Closed,
+		Labels: issue.Labels,
+	}
+	if answer.State == "" {
+		answer.State = IssueStateOpen
+		if answer.Closed {
+			answer.State = IssueStateClosed
+		}
+	}
+	if issue.Created != nil {
+		answer.Created = *issue.Created
+	}
+	if issue.Updated != nil {
+		answer.Updated = *issue.Updated
+	}
+	if issue.Author != nil {
+		answer.Author = externalToGitUser(issue.Author)
+	}
+	for k := range issue.Assignees {
+		answer.Assignees = append(answer.Assignees, externalToGitUser(&issue.Assignees[k]))
+	}
+	return answer
+}
+
+func externalToGitUser(user *ExternalUser) scm.User {
+	return scm.User{
+		Login:  user.Login,
+		Name:   user.Name,
+		Email:  user.Email,
+		Avatar: user.AvatarURL,
+		Link:   user.URL,
+	}
+}
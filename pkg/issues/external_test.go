@@ -0,0 +1,127 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const externalScript = `#!/bin/sh
+request=$(cat)
+case "$request" in
+*'"operation":"getIssue"'*'"key":"TICKET-1"'*)
+  echo '{"issue":{"key":"TICKET-1","title":"the login page is broken","url":"https://tickets.example.com/TICKET-1","closed":true,"labels":["bug"],"author":{"login":"jstrachan","name":"James Strachan"},"assignees":[{"login":"rawlingsj"}],"created":"2021-01-02T10:00:00Z"}}'
+  ;;
+*'"operation":"getIssue"'*)
+  echo '{}'
+  ;;
+*'"operation":"comment"'*)
+  echo "$request" >> comments.json
+  ;;
+*'"operation":"homeURL"'*)
+  echo "homeURL" >> calls.txt
+  echo "{\"url\":\"https://tickets.example.com/?token=${TICKETS_TOKEN}&git=${GIT_API_TOKEN}\"}"
+  ;;
+*'"operation":"searchIssues"'*)
+  echo '{"error":"searching is not supported"}'
+  ;;
+*)
+  echo "unknown operation" >&2
+  exit 1
+  ;;
+esac
+`
+
+func createExternalIssueProvider(t *testing.T) (issues.IssueProvider, string) {
+	dir := t.TempDir()
+	command := filepath.Join(dir, "tickets.sh")
+	err := os.WriteFile(command, []byte(externalScript), 0o755)
+	require.NoError(t, err)
+	tracker, err := issues.NewExternalIssueProvider("tickets", dir, command, "https://tickets.example.com/browse/{{ .Key }}", nil, []string{"TICKETS_TOKEN"}, regexp.MustCompile(`TICKET-\d+`))
+	require.NoError(t, err)
+	return tracker, dir
+}
+
+func TestExternalGetIssue(t *testing.T) {
+	tracker, _ := createExternalIssueProvider(t)
+	assert.Equal(t, issues.External, issues.GetIssueProvider(tracker))
+
+	issue, err := tracker.GetIssue("TICKET-1")
+	require.NoError(t, err)
+	require.NotNil(t, issue)
+	assert.Equal(t, "the login page is broken", issue.Title)
+	assert.Equal(t, "https://tickets.example.com/TICKET-1", issue.Link)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, issues.IssueStateClosed, issue.State)
+	assert.Equal(t, []string{"bug"}, issue.Labels)
+	assert.Equal(t, "James Strachan", issue.Author.Name)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "rawlingsj", issue.Assignees[0].Login)
+	assert.Equal(t, time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC), issue.Created.UTC())
+
+	issue, err = tracker.GetIssue("TICKET-2")
+	require.NoError(t, err)
+	assert.Nil(t, issue)
+
+	assert.Equal(t, "https://tickets.example.com/TICKET-1", tracker.IssueURL("TICKET-1"), "the URL returned by the command is used")
+	assert.Equal(t, "https://tickets.example.com/browse/TICKET-3", tracker.IssueURL("TICKET-3"), "other URLs are created from the template")
+}
+
+func TestExternalCreateIssueComment(t *testing.T) {
+	tracker, dir := createExternalIssueProvider(t)
+
+	err := tracker.CreateIssueComment("TICKET-1", "fixed in v1.2.3")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "comments.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"operation":"comment","key":"TICKET-1","comment":"fixed in v1.2.3"}`, string(data))
+}
+
+func TestExternalErrors(t *testing.T) {
+	tracker, _ := createExternalIssueProvider(t)
+
+	_, err := tracker.SearchIssues("login")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "searching is not supported")
+
+	_, err = tracker.(issues.IssueCommentLister).GetIssueComments("TICKET-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown operation")
+
+	_, err = issues.NewExternalIssueProvider("tickets", "", "tickets.sh", "", nil, nil, nil)
+	assert.Error(t, err)
+
+	_, err = issues.NewExternalIssueProvider("tickets", "", "tickets.sh", "{{ .Key", nil, nil, regexp.MustCompile(`TICKET-\d+`))
+	assert.Error(t, err)
+}
+
+func TestExternalEnvironment(t *testing.T) {
+	t.Setenv("TICKETS_TOKEN", "secret")
+	t.Setenv("GIT_API_TOKEN", "git-secret")
+	tracker, _ := createExternalIssueProvider(t)
+
+	assert.Equal(t, "https://tickets.example.com/?token=secret&git=", tracker.HomeURL(), "only the listed environment variables are passed to the command")
+}
+
+func TestExternalHomeURLIsOnlyRequestedOnce(t *testing.T) {
+	t.Setenv("TICKETS_TOKEN", "")
+	t.Setenv("GIT_API_TOKEN", "")
+	tracker, dir := createExternalIssueProvider(t)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "https://tickets.example.com/?token=&git=", tracker.HomeURL())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "calls.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "homeURL\n", string(data))
+}
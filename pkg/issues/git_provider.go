@@ -2,14 +2,20 @@ package issues
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
 )
 
+// gitHubBatchSize the maximum number of issues looked up with one GitHub GraphQL query
+const gitHubBatchSize = 50
+
 type GitIssueProvider struct {
 	GitProvider *scm.Client
 	Owner       string
@@ -34,7 +40,10 @@ func CreateGitIssueProvider(scmClient *scm.Client, owner, repository string) (Is
 }
 
 func (i *GitIssueProvider) GetIssue(key string) (*scm.Issue, error) {
-	ctx := context.Background()
+	return i.GetIssueWithContext(context.Background(), key)
+}
+
+func (i *GitIssueProvider) GetIssueWithContext(ctx context.Context, key string) (*scm.Issue, error) {
 	n, err := issueKeyToNumber(key)
 	if err != nil {
 		return nil, err
@@ -98,6 +107,187 @@ func (i *GitIssueProvider) CreateIssueComment(key, comment string) error {
 	return nil
 }
 
+func (i *GitIssueProvider) GetIssueComments(key string) ([]string, error) {
+	ctx := context.Background()
+	n, err := issueKeyToNumber(key)
+	if err != nil {
+		return nil, err
+	}
+	var answer []string
+	opts := &scm.ListOptions{Size: 100}
+	for opts.Page = 1; ; opts.Page++ {
+		comments, _, err := i.GitProvider.Issues.ListComments(ctx, i.fullName, n, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list comments of issue %d on repository %s: %w", n, i.fullName, err)
+		}
+		for _, c := range comments {
+			answer = append(answer, c.Body)
+		}
+		if len(comments) < opts.Size {
+			return answer, nil
+		}
+	}
+}
+
 func (i *GitIssueProvider) HomeURL() string {
 	return stringhelpers.UrlJoin(i.GitProvider.BaseURL.String(), i.Owner, i.Repository)
 }
+
+// GetIssues looks up the issues and pull requests with GitHub GraphQL queries of up to 50 issues. Other git
+// providers look up the issues one at a time
+func (i *GitIssueProvider) GetIssues(ctx context.Context, keys []string) (map[string]*scm.Issue, error) {
+	if !i.usesGraphQL() {
+		return getIssuesOneByOne(ctx, keys, i.GetIssueWithContext)
+	}
+	answer := map[string]*scm.Issue{}
+	failed := IssueErrors{}
+	for _, batch := range chunkKeys(keys, gitHubBatchSize) {
+		err := i.getGitHubIssues(ctx, batch, answer, failed)
+		if err != nil {
+			return answer, err
+		}
+	}
+	if len(failed) > 0 {
+		return answer, failed
+	}
+	return answer, nil
+}
+
+// usesGraphQL returns true if issues are looked up with GitHub GraphQL queries
+func (i *GitIssueProvider) usesGraphQL() bool {
+	return i.GitProvider.Driver == scm.DriverGithub && i.GitProvider.GraphQLURL != nil
+}
+
+// gitHubIssueFields the fields of issues and pull requests looked up with GraphQL
+const gitHubIssueFields = `number title body url state closed createdAt updatedAt
+author { login avatarUrl url }
+assignees(first: 20) { nodes { login name email avatarUrl url } }
+labels(first: 50) { nodes { name } }`
+
+type gitHubUser struct {
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatarUrl"`
+	URL       string `json:"url"`
+}
+
+type gitHubIssue struct {
+	TypeName  string      `json:"__typename"`
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	URL       string      `json:"url"`
+	State     string      `json:"state"`
+	Closed    bool        `json:"closed"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Author    *gitHubUser `json:"author"`
+	Assignees struct {
+		Nodes []gitHubUser `json:"nodes"`
+	} `json:"assignees"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+}
+
+// getGitHubIssues looks up the issues with a single GraphQL query with an alias per issue such as i0 for the first
+// key. Issues which are not found are left out and keys which are not numbers are added to failed
+func (i *GitIssueProvider) getGitHubIssues(ctx context.Context, keys []string, answer map[string]*scm.Issue, failed IssueErrors) error {
+	aliases := map[string]string{}
+	var query strings.Builder
+	query.WriteString("query Issues($owner: String!, $name: String!) {\n  repository(owner: $owner, name: $name) {\n")
+	for _, key := range keys {
+		n, err := issueKeyToNumber(key)
+		if err != nil {
+			failed[key] = err
+			continue
+		}
+		alias := fmt.Sprintf("i%d", len(aliases))
+		aliases[alias] = key
+		fmt.Fprintf(&query, "    %s: issueOrPullRequest(number: %d) { __typename ... on Issue { ...issueFields } ... on PullRequest { ...pullRequestFields } }\n", alias, n)
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+	query.WriteString("  }\n}\n")
+	query.WriteString("fragment issueFields on Issue {\n" + gitHubIssueFields + "\n}\n")
+	query.WriteString("fragment pullRequestFields on PullRequest {\n" + gitHubIssueFields + "\n}\n")
+
+	variables := map[string]interface{}{
+		"owner": i.Owner,
+		"name":  i.Repository,
+	}
+	resp := &graphQLResponse{}
+	err := doJSONWithContext(ctx, i.GitProvider.Client, http.MethodPost, i.GitProvider.GraphQLURL.String(), nil, &graphQLRequest{Query: query.String(), Variables: variables}, resp)
+	if err != nil {
+		return fmt.Errorf("failed to query issues of repository %s: %w", i.fullName, err)
+	}
+	for _, e := range resp.Errors {
+		// issues which do not exist are reported as NOT_FOUND errors with the path of their alias
+		if e.Type == "NOT_FOUND" && len(e.Path) == 2 {
+			continue
+		}
+		return fmt.Errorf("failed to query issues of repository %s: %s", i.fullName, e.Message)
+	}
+	data := struct {
+		Repository map[string]*gitHubIssue `json:"repository"`
+	}{}
+	if len(resp.Data) > 0 {
+		err = json.Unmarshal(resp.Data, &data)
+		if err != nil {
+			return fmt.Errorf("failed to parse issues of repository %s: %w", i.fullName, err)
+		}
+	}
+	for alias, issue := range data.Repository {
+		if issue != nil && aliases[alias] != "" {
+			answer[aliases[alias]] = gitHubToGitIssue(issue)
+		}
+	}
+	return nil
+}
+
+func gitHubToGitIssue(issue *gitHubIssue) *scm.Issue {
+	answer := &scm.Issue{
+		Number:  issue.Number,
+		Title:   issue.Title,
+		Body:    issue.Body,
+		Link:    issue.URL,
+		Closed:  issue.Closed,
+		State:   IssueStateOpen,
+		Created: issue.CreatedAt,
+		Updated: issue.UpdatedAt,
+	}
+	// the REST API reports merged pull requests as closed
+	if issue.Closed {
+		answer.State = IssueStateClosed
+	}
+	if issue.Author != nil {
+		answer.Author = gitHubToGitUser(issue.Author)
+	}
+	for k := range issue.Assignees.Nodes {
+		answer.Assignees = append(answer.Assignees, gitHubToGitUser(&issue.Assignees.Nodes[k]))
+	}
+	for _, l := range issue.Labels.Nodes {
+		answer.Labels = append(answer.Labels, l.Name)
+	}
+	if issue.TypeName == "PullRequest" {
+		answer.PullRequest = &scm.PullRequest{
+			Link:     issue.URL,
+			DiffLink: issue.URL + ".diff",
+		}
+	}
+	return answer
+}
+
+func gitHubToGitUser(user *gitHubUser) scm.User {
+	return scm.User{
+		Login:  user.Login,
+		Name:   user.Name,
+		Email:  user.Email,
+		Avatar: user.AvatarURL,
+		Link:   user.URL,
+	}
+}
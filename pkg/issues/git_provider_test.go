@@ -0,0 +1,91 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gitHubIssuesJSON = `{
+  "data": {
+    "repository": {
+      "i0": {
+        "__typename": "Issue",
+        "number": 12,
+        "title": "the login page is broken",
+        "url": "https://github.com/jstrachan/foo/issues/12",
+        "state": "CLOSED",
+        "closed": true,
+        "createdAt": "2021-01-02T10:00:00Z",
+        "author": {"login": "jstrachan", "avatarUrl": "https://avatars.example.com/jstrachan"},
+        "assignees": {"nodes": [{"login": "rawlingsj", "name": "James Rawlings"}]},
+        "labels": {"nodes": [{"name": "bug"}]}
+      },
+      "i1": {
+        "__typename": "PullRequest",
+        "number": 13,
+        "title": "fix the login page",
+        "url": "https://github.com/jstrachan/foo/pull/13",
+        "state": "MERGED",
+        "closed": true,
+        "author": {"login": "rawlingsj"},
+        "assignees": {"nodes": []},
+        "labels": {"nodes": []}
+      },
+      "i2": null
+    }
+  },
+  "errors": [{"type": "NOT_FOUND", "path": ["repository", "i2"], "message": "Could not resolve to an issue or pull request with the number of 404."}]
+}`
+
+func TestGitHubGetIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		req := map[string]interface{}{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		assert.NoError(t, err)
+		assert.Contains(t, req["query"], "i0: issueOrPullRequest(number: 12)")
+		assert.Contains(t, req["query"], "i2: issueOrPullRequest(number: 404)")
+		assert.Equal(t, map[string]interface{}{"owner": "jstrachan", "name": "foo"}, req["variables"])
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(gitHubIssuesJSON))
+	}))
+	defer server.Close()
+
+	scmClient, err := github.New(server.URL)
+	require.NoError(t, err)
+	tracker, err := issues.CreateGitIssueProvider(scmClient, "jstrachan", "foo")
+	require.NoError(t, err)
+
+	found, err := issues.AsBatchIssueProvider(tracker).GetIssues(context.Background(), []string{"12", "13", "404", "abc"})
+	require.Len(t, found, 2)
+
+	issue := found["12"]
+	assert.Equal(t, "the login page is broken", issue.Title)
+	assert.Equal(t, issues.IssueStateClosed, issue.State)
+	assert.Equal(t, "jstrachan", issue.Author.Login)
+	assert.Equal(t, "https://avatars.example.com/jstrachan", issue.Author.Avatar)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "James Rawlings", issue.Assignees[0].Name)
+	assert.Equal(t, []string{"bug"}, issue.Labels)
+	assert.Nil(t, issue.PullRequest)
+
+	pr := found["13"]
+	assert.Equal(t, issues.IssueStateClosed, pr.State)
+	require.NotNil(t, pr.PullRequest)
+	assert.Equal(t, "https://github.com/jstrachan/foo/pull/13", pr.PullRequest.Link)
+
+	var errs issues.IssueErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 1, "only the key which is not a number fails")
+	assert.Error(t, errs["abc"])
+}
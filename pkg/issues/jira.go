@@ -2,8 +2,15 @@ package issues
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/andygrunwald/go-jira"
@@ -12,46 +19,172 @@ import (
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
+// jiraBatchSize the maximum number of issues looked up with one JQL search
+const jiraBatchSize = 100
+
 type JiraService struct {
 	JiraClient *jira.Client
 	ServerURL  string
 	Project    string
 }
 
+// JiraOptions the settings to connect to a Jira server
+type JiraOptions struct {
+	ServerURL string
+	Username  string
+	Project   string
+
+	// Token the API token, personal access token or OAuth 2.0 access token depending on the AuthMode
+	Token string
+
+	// AuthMode how to authenticate with the token. One of basic or bearer. Defaults to basic
+	AuthMode string
+
+	// CAFile the file containing PEM encoded certificates to trust in addition to the system ones
+	CAFile string
+
+	// InsecureSkipVerify disables the verification of the server certificate
+	InsecureSkipVerify bool
+}
+
 func CreateJiraIssueProvider(serverURL, username, apiToken, project string, batchMode bool) (IssueProvider, error) {
+	return NewJiraIssueProvider(&JiraOptions{
+		ServerURL: serverURL,
+		Username:  username,
+		Token:     apiToken,
+		Project:   project,
+	}, batchMode)
+}
+
+// NewJiraIssueProvider creates a Jira issue provider. Jira Cloud uses basic authentication with the user name and
+// an API token whereas Jira Server and Data Center use bearer authentication with a personal access token.
+func NewJiraIssueProvider(o *JiraOptions, batchMode bool) (IssueProvider, error) {
+	serverURL := o.ServerURL
 	if serverURL == "" {
 		return nil, fmt.Errorf("no JIRA server URL for server")
 	}
-	var httpClient *http.Client
-	if apiToken != "" {
+	transport, err := jiraTransport(o)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := &http.Client{Transport: transport}
+	switch {
+	case o.Token == "":
+		if batchMode {
+			log.Logger().Warnf("No authentication found for JIRA server %s so using anonymous access", serverURL)
+		}
+	case o.AuthMode == JiraAuthBearer:
+		tp := jira.BearerAuthTransport{
+			Token:     o.Token,
+			Transport: transport,
+		}
+		httpClient = tp.Client()
+		if batchMode {
+			log.Logger().Infof("Using JIRA server %s with a bearer token", serverURL)
+		}
+	case o.AuthMode == "" || o.AuthMode == JiraAuthBasic:
 		tp := jira.BasicAuthTransport{
-			Username: username,
-			Password: apiToken,
+			Username:  o.Username,
+			Password:  o.Token,
+			Transport: transport,
 		}
 		httpClient = tp.Client()
 		if batchMode {
-			log.Logger().Infof("Using JIRA server %s user name %s and an API token", serverURL, username)
+			log.Logger().Infof("Using JIRA server %s user name %s and an API token", serverURL, o.Username)
 		}
-	} else if batchMode {
-		log.Logger().Warnf("No authentication found for JIRA server %s so using anonymous access", serverURL)
+	default:
+		return nil, fmt.Errorf("unknown JIRA auth mode %s should be %s or %s", o.AuthMode, JiraAuthBasic, JiraAuthBearer)
 	}
 
 	jiraClient, _ := jira.NewClient(httpClient, serverURL)
 	return &JiraService{
 		JiraClient: jiraClient,
 		ServerURL:  serverURL,
-		Project:    project,
+		Project:    o.Project,
 	}, nil
 }
 
+// jiraTransport returns the transport with the TLS settings of the options
+func jiraTransport(o *JiraOptions) (http.RoundTripper, error) {
+	if o.CAFile == "" && !o.InsecureSkipVerify {
+		return http.DefaultTransport, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // explicitly requested for servers with self signed certificates
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if o.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JIRA CA file %s: %w", o.CAFile, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in JIRA CA file %s", o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
 func (i *JiraService) GetIssue(key string) (*scm.Issue, error) {
-	issue, _, err := i.JiraClient.Issue.Get(key, nil)
+	return i.GetIssueWithContext(context.Background(), key)
+}
+
+func (i *JiraService) GetIssueWithContext(ctx context.Context, key string) (*scm.Issue, error) {
+	issue, _, err := i.JiraClient.Issue.GetWithContext(ctx, key, nil)
 	if err != nil {
 		return nil, err
 	}
 	return i.jiraToGitIssue(issue), nil
 }
 
+// GetIssues looks up the issues with JQL searches such as key in ("ABC-1", "ABC-2"). Keys which are not found such as
+// the old keys of moved issues are looked up one at a time
+func (i *JiraService) GetIssues(ctx context.Context, keys []string) (map[string]*scm.Issue, error) {
+	answer := map[string]*scm.Issue{}
+	var missing []string
+	for _, batch := range chunkKeys(keys, jiraBatchSize) {
+		var quoted []string
+		requested := map[string]bool{}
+		for _, key := range batch {
+			quoted = append(quoted, strconv.Quote(key))
+			requested[key] = true
+		}
+		jql := "key in (" + strings.Join(quoted, ", ") + ")"
+		// warn so that keys of issues which do not exist don't fail the whole search
+		opts := &jira.SearchOptions{MaxResults: len(batch), ValidateQuery: "warn"}
+		found, _, err := i.JiraClient.Issue.SearchWithContext(ctx, jql, opts)
+		if err != nil {
+			return answer, fmt.Errorf("failed to search for issues with %s: %w", jql, err)
+		}
+		for k := range found {
+			if requested[found[k].Key] {
+				answer[found[k].Key] = i.jiraToGitIssue(&found[k])
+			}
+		}
+		for _, key := range batch {
+			if answer[key] == nil {
+				missing = append(missing, key)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return answer, nil
+	}
+	found, err := getIssuesOneByOne(ctx, missing, i.GetIssueWithContext)
+	for k, v := range found {
+		answer[k] = v
+	}
+	return answer, err
+}
+
 func (i *JiraService) SearchIssues(query string) ([]*scm.Issue, error) {
 	jql := "project = " + i.Project + " AND status NOT IN (Closed, Resolved)"
 	if query != "" {
@@ -100,8 +233,28 @@ func (i *JiraService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
 	return i.jiraToGitIssue(created), nil
 }
 
-func (i *JiraService) CreateIssueComment(_, _ string) error {
-	return fmt.Errorf("TODO")
+func (i *JiraService) CreateIssueComment(key, comment string) error {
+	_, _, err := i.JiraClient.Issue.AddComment(key, &jira.Comment{Body: comment})
+	if err != nil {
+		return fmt.Errorf("failed to add comment to issue %s: %w", key, err)
+	}
+	return nil
+}
+
+func (i *JiraService) GetIssueComments(key string) ([]string, error) {
+	issue, _, err := i.JiraClient.Issue.Get(key, &jira.GetQueryOptions{Fields: "comment"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments of issue %s: %w", key, err)
+	}
+	var answer []string
+	if issue.Fields != nil && issue.Fields.Comments != nil {
+		for _, c := range issue.Fields.Comments.Comments {
+			if c != nil {
+				answer = append(answer, c.Body)
+			}
+		}
+	}
+	return answer, nil
 }
 
 func (i *JiraService) IssueURL(key string) string {
@@ -111,14 +264,20 @@ func (i *JiraService) IssueURL(key string) string {
 func (i *JiraService) jiraToGitIssue(issue *jira.Issue) *scm.Issue {
 	answer := &scm.Issue{}
 	key := issue.Key
-	// TODO: answer.Key = key
+	answer.Number = jiraIssueNumber(key)
 	answer.Link = i.IssueURL(key)
+	answer.State = IssueStateOpen
 	fields := issue.Fields
 	if fields != nil {
 		answer.Title = fields.Summary
 		answer.Body = fields.Description
-		// TODO: answer.Labels = gits.ToGitLabels(fields.Labels)
-		// TODO: answer.ClosedAt = jiraTimeToTimeP(fields.Resolutiondate)
+		answer.Labels = jiraLabels(fields)
+		answer.Created = time.Time(fields.Created)
+		answer.Updated = time.Time(fields.Updated)
+		if fields.Resolution != nil || (fields.Status != nil && fields.Status.StatusCategory.Key == jira.StatusCategoryComplete) {
+			answer.Closed = true
+			answer.State = IssueStateClosed
+		}
 		user := i.jiraUserToGitUser(fields.Reporter)
 		if user != nil {
 			answer.Author = *user
@@ -131,6 +290,45 @@ func (i *JiraService) jiraToGitIssue(issue *jira.Issue) *scm.Issue {
 	return answer
 }
 
+// jiraLabels returns the labels of the issue followed by labels for the issue type, status, resolution, resolution
+// date, components and fix versions such as type/Bug so that they can be used to group and filter issues
+func jiraLabels(fields *jira.IssueFields) []string {
+	answer := append([]string{}, fields.Labels...)
+	if fields.Type.Name != "" {
+		answer = append(answer, JiraTypeLabelPrefix+fields.Type.Name)
+	}
+	if fields.Status != nil && fields.Status.Name != "" {
+		answer = append(answer, JiraStatusLabelPrefix+fields.Status.Name)
+	}
+	if fields.Resolution != nil && fields.Resolution.Name != "" {
+		answer = append(answer, JiraResolutionLabelPrefix+fields.Resolution.Name)
+	}
+	if resolved := time.Time(fields.Resolutiondate); !resolved.IsZero() {
+		answer = append(answer, JiraResolutionDateLabelPrefix+resolved.UTC().Format(JiraResolutionDateFormat))
+	}
+	for _, c := range fields.Components {
+		if c != nil && c.Name != "" {
+			answer = append(answer, JiraComponentLabelPrefix+c.Name)
+		}
+	}
+	for _, v := range fields.FixVersions {
+		if v != nil && v.Name != "" {
+			answer = append(answer, JiraFixVersionLabelPrefix+v.Name)
+		}
+	}
+	return answer
+}
+
+// jiraIssueNumber returns the number of an issue key such as 123 for ABC-123
+func jiraIssueNumber(key string) int {
+	idx := strings.LastIndex(key, "-")
+	n, err := strconv.Atoi(key[idx+1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
 func (i *JiraService) jiraUserToGitUser(user *jira.User) *scm.User {
 	if user == nil {
 		return nil
@@ -184,3 +382,25 @@ func (i *JiraService) ServerName() string {
 func (i *JiraService) HomeURL() string {
 	return stringhelpers.UrlJoin(i.ServerURL, "browse", i.Project)
 }
+
+// JiraIssueKeyRegexp returns the regular expression matching the keys of issues in the Jira projects such as
+// ABC-123 for the project ABC
+func JiraIssueKeyRegexp(projects ...string) *regexp.Regexp {
+	return issueKeyRegexp(projects)
+}
+
+// issueKeyRegexp returns the regular expression matching issue keys with one of the prefixes followed by a dash and
+// a number or nil if there are no prefixes
+func issueKeyRegexp(prefixes []string) *regexp.Regexp {
+	var keys []string
+	for _, p := range prefixes {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			keys = append(keys, regexp.QuoteMeta(p))
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(keys, "|") + `)-\d+\b`)
+}
@@ -0,0 +1,199 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jiraIssueJSON = `{
+  "key": "ABC-123",
+  "fields": {
+    "summary": "the login page is broken",
+    "description": "it does not work",
+    "issuetype": {"name": "Bug"},
+    "labels": ["ui"],
+    "components": [{"name": "Frontend"}],
+    "fixVersions": [{"name": "1.2.0"}],
+    "status": {"name": "Done", "statusCategory": {"key": "done"}},
+    "resolution": {"name": "Fixed"},
+    "created": "2021-01-02T10:00:00.000+0000",
+    "updated": "2021-01-03T10:00:00.000+0000",
+    "resolutiondate": "2021-01-04T10:00:00.000+0000",
+    "reporter": {"displayName": "James Strachan", "emailAddress": "jstrachan@example.com", "accountId": "123"}
+  }
+}`
+
+func TestJiraGetIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/issue/ABC-123", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(jiraIssueJSON))
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateJiraIssueProvider(server.URL, "", "", "ABC", false)
+	require.NoError(t, err)
+
+	issue, err := tracker.GetIssue("ABC-123")
+	require.NoError(t, err)
+
+	assert.Equal(t, 123, issue.Number)
+	assert.Equal(t, "the login page is broken", issue.Title)
+	assert.Equal(t, server.URL+"/browse/ABC-123", issue.Link)
+	assert.Equal(t, issues.IssueStateClosed, issue.State)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, []string{"ui", "type/Bug", "status/Done", "resolution/Fixed", "resolved/2021-01-04", "component/Frontend", "fixVersion/1.2.0"}, issue.Labels)
+	assert.Equal(t, time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC), issue.Created.UTC())
+	assert.Equal(t, time.Date(2021, 1, 3, 10, 0, 0, 0, time.UTC), issue.Updated.UTC())
+	assert.Equal(t, "James Strachan", issue.Author.Name)
+}
+
+func TestJiraGetIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			assert.Equal(t, `key in ("ABC-123", "ABC-7", "ABC-404")`, r.URL.Query().Get("jql"))
+			assert.Equal(t, "warn", r.URL.Query().Get("validateQuery"))
+			_, _ = w.Write([]byte(`{"issues": [` + jiraIssueJSON + `, {"key": "XYZ-1", "fields": {"summary": "moved"}}]}`))
+		case "/rest/api/2/issue/ABC-7":
+			// a moved issue is found by its old key
+			_, _ = w.Write([]byte(`{"key": "XYZ-1", "fields": {"summary": "moved"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errorMessages": ["Issue does not exist"]}`))
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateJiraIssueProvider(server.URL, "", "", "ABC", false)
+	require.NoError(t, err)
+
+	found, err := issues.AsBatchIssueProvider(tracker).GetIssues(context.Background(), []string{"ABC-123", "ABC-7", "ABC-404"})
+	require.Len(t, found, 2)
+	assert.Equal(t, "the login page is broken", found["ABC-123"].Title)
+	assert.Equal(t, "moved", found["ABC-7"].Title)
+
+	var errs issues.IssueErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 1)
+	assert.Error(t, errs["ABC-404"])
+}
+
+func TestJiraSetFixVersion(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(body)))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /rest/api/2/project/ABC":
+			_, _ = w.Write([]byte(`{"id": "10000", "key": "ABC", "versions": [{"id": "1", "name": "1.0.0", "released": true}]}`))
+		case "POST /rest/api/2/version":
+			_, _ = w.Write([]byte(`{"id": "2", "name": "1.1.0", "projectId": 10000}`))
+		case "GET /rest/api/2/issue/ABC-1":
+			_, _ = w.Write([]byte(`{"key": "ABC-1", "fields": {"fixVersions": []}}`))
+		case "GET /rest/api/2/issue/ABC-2":
+			_, _ = w.Write([]byte(`{"key": "ABC-2", "fields": {"fixVersions": [{"id": "2", "name": "1.1.0"}]}}`))
+		case "PUT /rest/api/2/issue/ABC-1", "PUT /rest/api/2/version/2":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.CreateJiraIssueProvider(server.URL, "", "", "ABC", false)
+	require.NoError(t, err)
+
+	err = tracker.(issues.FixVersionTracker).SetFixVersion("1.1.0", []string{"ABC-1", "ABC-2"}, true)
+	require.NoError(t, err)
+
+	today := time.Now().Format("2006-01-02")
+	assert.Equal(t, []string{
+		"GET /rest/api/2/project/ABC ",
+		`POST /rest/api/2/version {"name":"1.1.0","projectId":10000}`,
+		"GET /rest/api/2/issue/ABC-1 ",
+		`PUT /rest/api/2/issue/ABC-1 {"update":{"fixVersions":[{"add":{"id":"2"}}]}}`,
+		"GET /rest/api/2/issue/ABC-2 ",
+		`PUT /rest/api/2/version/2 {"id":"2","released":true,"releaseDate":"` + today + `"}`,
+	}, requests)
+}
+
+func TestJiraAuthModes(t *testing.T) {
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(jiraIssueJSON))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		options  issues.JiraOptions
+		expected string
+	}{
+		{
+			name:     "basic",
+			options:  issues.JiraOptions{Username: "jstrachan", Token: "secret", CAFile: caFile},
+			expected: "Basic " + base64.StdEncoding.EncodeToString([]byte("jstrachan:secret")),
+		},
+		{
+			name:     "bearer",
+			options:  issues.JiraOptions{Token: "secret", AuthMode: issues.JiraAuthBearer, CAFile: caFile},
+			expected: "Bearer secret",
+		},
+		{
+			name:    "insecure",
+			options: issues.JiraOptions{InsecureSkipVerify: true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authorization = ""
+			tc.options.ServerURL = server.URL
+			tracker, err := issues.NewJiraIssueProvider(&tc.options, false)
+			require.NoError(t, err)
+
+			_, err = tracker.GetIssue("ABC-123")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, authorization)
+		})
+	}
+
+	_, err = issues.NewJiraIssueProvider(&issues.JiraOptions{ServerURL: server.URL, Token: "secret", AuthMode: "oauth1"}, false)
+	require.Error(t, err)
+
+	tracker, err := issues.NewJiraIssueProvider(&issues.JiraOptions{ServerURL: server.URL}, false)
+	require.NoError(t, err)
+	_, err = tracker.GetIssue("ABC-123")
+	require.Error(t, err, "the self signed certificate should not be trusted by default")
+}
+
+func TestJiraIssueKeyRegexp(t *testing.T) {
+	r := issues.JiraIssueKeyRegexp("ABC", "DEF_2")
+	message := "fix: ABC-123 and DEF_2-4 use UTF-8 and SHA-256 not XABC-1 for CVE-2024-1234"
+	assert.Equal(t, []string{"ABC-123", "DEF_2-4"}, r.FindAllString(message, -1))
+
+	assert.Nil(t, issues.JiraIssueKeyRegexp())
+}
@@ -0,0 +1,139 @@
+package issues
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// FixVersionTracker is implemented by issue trackers which track the versions issues are fixed in
+type FixVersionTracker interface {
+	// SetFixVersion adds the version to the fix versions of the issues creating it if it does not exist. If
+	// release is true the version is marked as released
+	SetFixVersion(version string, keys []string, release bool) error
+}
+
+// SetFixVersion adds the version to the fix versions of the issues. The version is created in the project of each
+// issue if it does not exist yet. Issues which already have the fix version are left unchanged.
+func (i *JiraService) SetFixVersion(name string, keys []string, release bool) error {
+	versions := map[string]*jira.Version{}
+	var failed []string
+	for _, key := range keys {
+		projectKey := jiraProjectKey(key)
+		if projectKey == "" {
+			projectKey = i.Project
+		}
+		version := versions[projectKey]
+		if version == nil {
+			var err error
+			version, err = i.findOrCreateVersion(projectKey, name)
+			if err != nil {
+				return err
+			}
+			versions[projectKey] = version
+		}
+		err := i.addFixVersion(key, version)
+		if err != nil {
+			log.Logger().Warnf("%s", err)
+			failed = append(failed, key)
+		}
+	}
+	if release {
+		for projectKey, version := range versions {
+			err := i.releaseVersion(version)
+			if err != nil {
+				return fmt.Errorf("failed to release version %s of project %s: %w", name, projectKey, err)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to set fix version %s on issues %s", name, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (i *JiraService) findOrCreateVersion(projectKey, name string) (*jira.Version, error) {
+	project, _, err := i.JiraClient.Project.Get(projectKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not find project %s: %w", projectKey, err)
+	}
+	for k := range project.Versions {
+		if project.Versions[k].Name == name {
+			return &project.Versions[k], nil
+		}
+	}
+	projectID, err := strconv.Atoi(project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %s of project %s: %w", project.ID, projectKey, err)
+	}
+	version, resp, err := i.JiraClient.Version.Create(&jira.Version{
+		Name:      name,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create version %s in project %s: %w", name, projectKey, jira.NewJiraError(resp, err))
+	}
+	log.Logger().Infof("created version %s in Jira project %s", name, projectKey)
+	return version, nil
+}
+
+func (i *JiraService) addFixVersion(key string, version *jira.Version) error {
+	issue, _, err := i.JiraClient.Issue.Get(key, &jira.GetQueryOptions{Fields: "fixVersions"})
+	if err != nil {
+		return fmt.Errorf("failed to get fix versions of issue %s: %w", key, err)
+	}
+	if issue.Fields != nil {
+		for _, v := range issue.Fields.FixVersions {
+			if v != nil && (v.ID == version.ID || v.Name == version.Name) {
+				return nil
+			}
+		}
+	}
+	resp, err := i.JiraClient.Issue.UpdateIssue(key, map[string]interface{}{
+		"update": map[string]interface{}{
+			"fixVersions": []map[string]interface{}{
+				{"add": map[string]string{"id": version.ID}},
+			},
+		},
+	})
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add fix version %s to issue %s: %w", version.Name, key, err)
+	}
+	return nil
+}
+
+func (i *JiraService) releaseVersion(version *jira.Version) error {
+	if version.Released != nil && *version.Released {
+		return nil
+	}
+	released := true
+	_, resp, err := i.JiraClient.Version.Update(&jira.Version{
+		ID:          version.ID,
+		Released:    &released,
+		ReleaseDate: time.Now().Format("2006-01-02"),
+	})
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("released version %s in Jira", version.Name)
+	return nil
+}
+
+// jiraProjectKey returns the project key of an issue key such as ABC for ABC-123
+func jiraProjectKey(key string) string {
+	idx := strings.LastIndex(key, "-")
+	if idx < 0 {
+		return ""
+	}
+	return key[:idx]
+}
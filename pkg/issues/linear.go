@@ -0,0 +1,358 @@
+package issues
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+const (
+	// LinearAPIURL the default URL of the Linear GraphQL API
+	LinearAPIURL = "https://api.linear.app/graphql"
+
+	// LinearURL the URL of the Linear web application
+	LinearURL = "https://linear.app"
+)
+
+// linearIssueFields the fields of issues queried from Linear
+const linearIssueFields = `
+  id
+  identifier
+  number
+  title
+  description
+  url
+  createdAt
+  updatedAt
+  state { name type }
+  labels { nodes { name } }
+  creator { name displayName email avatarUrl url }
+  assignee { name displayName email avatarUrl url }
+`
+
+// LinearService an issue provider for the issues of Linear teams
+type LinearService struct {
+	HTTPClient *http.Client
+	ServerURL  string
+	APIKey     string
+
+	// Teams the keys of the teams whose issues are referenced such as ENG for ENG-123
+	Teams []string
+}
+
+// LinearOptions the settings to connect to Linear
+type LinearOptions struct {
+	// ServerURL the URL of the GraphQL API. Defaults to LinearAPIURL
+	ServerURL string
+
+	// APIKey the personal API key
+	APIKey string
+
+	// Teams the keys of the teams whose issues are referenced such as ENG for ENG-123. The first team is used to
+	// create issues
+	Teams []string
+}
+
+type linearUser struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+	AvatarURL   string `json:"avatarUrl"`
+	URL         string `json:"url"`
+}
+
+type linearIssue struct {
+	ID          string    `json:"id"`
+	Identifier  string    `json:"identifier"`
+	Number      float64   `json:"number"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	State       *struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"state"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Creator  *linearUser `json:"creator"`
+	Assignee *linearUser `json:"assignee"`
+}
+
+type linearIssues struct {
+	Nodes    []linearIssue `json:"nodes"`
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+}
+
+// NewLinearIssueProvider creates an issue provider for the issues of the Linear teams
+func NewLinearIssueProvider(o *LinearOptions) (IssueProvider, error) {
+	if o.APIKey == "" {
+		return nil, fmt.Errorf("no Linear API key")
+	}
+	if len(o.Teams) == 0 {
+		return nil, fmt.Errorf("no Linear teams")
+	}
+	serverURL := o.ServerURL
+	if serverURL == "" {
+		serverURL = LinearAPIURL
+	}
+	return &LinearService{
+		HTTPClient: newHTTPClient(),
+		ServerURL:  serverURL,
+		APIKey:     o.APIKey,
+		Teams:      o.Teams,
+	}, nil
+}
+
+func (i *LinearService) GetIssue(key string) (*scm.Issue, error) {
+	issue, err := i.getIssue(key)
+	if err != nil || issue == nil {
+		return nil, err
+	}
+	return i.linearToGitIssue(issue), nil
+}
+
+func (i *LinearService) getIssue(key string) (*linearIssue, error) {
+	result := &struct {
+		Issue *linearIssue `json:"issue"`
+	}{}
+	err := i.do(`query Issue($id: String!) { issue(id: $id) {`+linearIssueFields+`} }`, map[string]interface{}{"id": key}, result)
+	if isLinearNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find issue %s: %w", key, err)
+	}
+	return result.Issue, nil
+}
+
+// isLinearNotFound returns true if the error is the response of an issue which does not exist
+func isLinearNotFound(err error) bool {
+	var errs graphQLErrors
+	if !errors.As(err, &errs) {
+		return false
+	}
+	for _, e := range errs {
+		if strings.HasPrefix(e.Message, "Entity not found") {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *LinearService) SearchIssues(query string) ([]*scm.Issue, error) {
+	filter := map[string]interface{}{
+		"team":  map[string]interface{}{"key": map[string]interface{}{"in": i.Teams}},
+		"state": map[string]interface{}{"type": map[string]interface{}{"nin": []string{"completed", "canceled"}}},
+	}
+	if query != "" {
+		filter["title"] = map[string]interface{}{"containsIgnoreCase": query}
+	}
+	return i.search(filter)
+}
+
+func (i *LinearService) SearchIssuesClosedSince(t time.Time) ([]*scm.Issue, error) {
+	filter := map[string]interface{}{
+		"team":        map[string]interface{}{"key": map[string]interface{}{"in": i.Teams}},
+		"completedAt": map[string]interface{}{"gte": t.UTC().Format(time.RFC3339)},
+	}
+	return i.search(filter)
+}
+
+// search pages through the issues matching the filter
+func (i *LinearService) search(filter map[string]interface{}) ([]*scm.Issue, error) {
+	var answer []*scm.Issue
+	variables := map[string]interface{}{"filter": filter}
+	for {
+		result := &struct {
+			Issues linearIssues `json:"issues"`
+		}{}
+		err := i.do(`query Issues($filter: IssueFilter, $after: String) { issues(filter: $filter, first: 100, after: $after) {
+  nodes {`+linearIssueFields+`}
+  pageInfo { hasNextPage endCursor }
+} }`, variables, result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search issues: %w", err)
+		}
+		for k := range result.Issues.Nodes {
+			answer = append(answer, i.linearToGitIssue(&result.Issues.Nodes[k]))
+		}
+		if !result.Issues.PageInfo.HasNextPage || result.Issues.PageInfo.EndCursor == "" {
+			return answer, nil
+		}
+		variables["after"] = result.Issues.PageInfo.EndCursor
+	}
+}
+
+func (i *LinearService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	teams := &struct {
+		Teams struct {
+			Nodes []struct {
+				ID string `json:"id"`
+			} `json:"nodes"`
+		} `json:"teams"`
+	}{}
+	team := i.Teams[0]
+	err := i.do(`query Teams($key: String!) { teams(filter: { key: { eq: $key } }) { nodes { id } } }`, map[string]interface{}{"key": team}, teams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find team %s: %w", team, err)
+	}
+	if len(teams.Teams.Nodes) == 0 {
+		return nil, fmt.Errorf("team %s not found", team)
+	}
+	result := &struct {
+		IssueCreate struct {
+			Success bool         `json:"success"`
+			Issue   *linearIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}{}
+	err = i.do(`mutation IssueCreate($input: IssueCreateInput!) { issueCreate(input: $input) { success issue {`+linearIssueFields+`} } }`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"teamId":      teams.Teams.Nodes[0].ID,
+			"title":       issue.Title,
+			"description": issue.Body,
+		},
+	}, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	if !result.IssueCreate.Success || result.IssueCreate.Issue == nil {
+		return nil, fmt.Errorf("failed to create issue in team %s", team)
+	}
+	return i.linearToGitIssue(result.IssueCreate.Issue), nil
+}
+
+func (i *LinearService) CreateIssueComment(key, comment string) error {
+	issue, err := i.getIssue(key)
+	if err != nil {
+		return err
+	}
+	if issue == nil {
+		return fmt.Errorf("issue %s not found", key)
+	}
+	result := &struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}{}
+	err = i.do(`mutation CommentCreate($input: CommentCreateInput!) { commentCreate(input: $input) { success } }`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"issueId": issue.ID,
+			"body":    comment,
+		},
+	}, result)
+	if err != nil {
+		return fmt.Errorf("failed to add comment to issue %s: %w", key, err)
+	}
+	if !result.CommentCreate.Success {
+		return fmt.Errorf("failed to add comment to issue %s", key)
+	}
+	return nil
+}
+
+func (i *LinearService) GetIssueComments(key string) ([]string, error) {
+	result := &struct {
+		Issue *struct {
+			Comments struct {
+				Nodes []struct {
+					Body string `json:"body"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"issue"`
+	}{}
+	err := i.do(`query IssueComments($id: String!) { issue(id: $id) { comments(first: 250) { nodes { body } } } }`, map[string]interface{}{"id": key}, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments of issue %s: %w", key, err)
+	}
+	if result.Issue == nil {
+		return nil, fmt.Errorf("issue %s not found", key)
+	}
+	var answer []string
+	for _, c := range result.Issue.Comments.Nodes {
+		answer = append(answer, c.Body)
+	}
+	return answer, nil
+}
+
+// IssueURL returns the URL of the issue. Linear redirects the URL without the workspace to the issue
+func (i *LinearService) IssueURL(key string) string {
+	return stringhelpers.UrlJoin(LinearURL, "issue", key)
+}
+
+func (i *LinearService) HomeURL() string {
+	return LinearURL
+}
+
+// linearToGitIssue converts an issue using the name of its workflow state as the state. Completed and canceled
+// issues are closed
+func (i *LinearService) linearToGitIssue(issue *linearIssue) *scm.Issue {
+	answer := &scm.Issue{
+		Number:  int(issue.Number),
+		Title:   issue.Title,
+		Body:    issue.Description,
+		Link:    issue.URL,
+		State:   IssueStateOpen,
+		Created: issue.CreatedAt,
+		Updated: issue.UpdatedAt,
+	}
+	if answer.Link == "" {
+		answer.Link = i.IssueURL(issue.Identifier)
+	}
+	if issue.State != nil {
+		if issue.State.Name != "" {
+			answer.State = issue.State.Name
+		}
+		answer.Closed = issue.State.Type == "completed" || issue.State.Type == "canceled"
+	}
+	for _, l := range issue.Labels.Nodes {
+		if l.Name != "" {
+			answer.Labels = append(answer.Labels, l.Name)
+		}
+	}
+	if user := linearUserToGitUser(issue.Creator); user != nil {
+		answer.Author = *user
+	}
+	if user := linearUserToGitUser(issue.Assignee); user != nil {
+		answer.Assignees = []scm.User{*user}
+	}
+	return answer
+}
+
+func linearUserToGitUser(user *linearUser) *scm.User {
+	if user == nil {
+		return nil
+	}
+	return &scm.User{
+		Login:  user.DisplayName,
+		Name:   user.Name,
+		Email:  user.Email,
+		Avatar: user.AvatarURL,
+		Link:   user.URL,
+	}
+}
+
+// do sends the GraphQL query authenticating with the API key and decodes the data of the response into result
+func (i *LinearService) do(query string, variables map[string]interface{}, result interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", i.APIKey)
+	return doGraphQL(i.HTTPClient, i.ServerURL, header, strings.TrimSpace(query), variables, result)
+}
+
+// LinearIssueKeyRegexp returns the regular expression matching the identifiers of issues of the Linear teams such
+// as ENG-123 for the team ENG
+func LinearIssueKeyRegexp(teams ...string) *regexp.Regexp {
+	return issueKeyRegexp(teams)
+}
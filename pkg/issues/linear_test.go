@@ -0,0 +1,113 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const linearIssueJSON = `{
+  "id": "b1a3c7e2-5d1c-4f0e-9a1e-0c6f2d1e4a11",
+  "identifier": "ENG-123",
+  "number": 123,
+  "title": "the login page is broken",
+  "description": "it does not work",
+  "url": "https://linear.app/example/issue/ENG-123/the-login-page-is-broken",
+  "createdAt": "2021-01-02T10:00:00.000Z",
+  "updatedAt": "2021-01-04T10:00:00.000Z",
+  "state": {"name": "Done", "type": "completed"},
+  "labels": {"nodes": [{"name": "Bug"}, {"name": "Frontend"}]},
+  "creator": {"name": "James Strachan", "displayName": "jstrachan", "email": "jstrachan@example.com"},
+  "assignee": {"name": "James Rawlings", "displayName": "rawlingsj", "email": "rawlingsj@example.com"}
+}`
+
+// linearServer a stand in for the Linear GraphQL API which records the comments created
+func linearServer(t *testing.T, comments *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "lin_api_key", r.Header.Get("Authorization"))
+		req := struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasPrefix(req.Query, "query Issue(") && req.Variables["id"] == "ENG-123":
+			_, _ = w.Write([]byte(`{"data": {"issue": ` + linearIssueJSON + `}}`))
+		case strings.HasPrefix(req.Query, "query Issue("):
+			_, _ = w.Write([]byte(`{"errors": [{"message": "Entity not found: Issue"}], "data": null}`))
+		case strings.HasPrefix(req.Query, "query IssueComments("):
+			_, _ = w.Write([]byte(`{"data": {"issue": {"comments": {"nodes": [{"body": "first"}]}}}}`))
+		case strings.HasPrefix(req.Query, "mutation CommentCreate("):
+			input := req.Variables["input"].(map[string]interface{})
+			assert.Equal(t, "b1a3c7e2-5d1c-4f0e-9a1e-0c6f2d1e4a11", input["issueId"])
+			*comments = append(*comments, input["body"].(string))
+			_, _ = w.Write([]byte(`{"data": {"commentCreate": {"success": true}}}`))
+		default:
+			t.Errorf("unexpected query %s", req.Query)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func TestLinearGetIssue(t *testing.T) {
+	server := linearServer(t, nil)
+	defer server.Close()
+
+	tracker, err := issues.NewLinearIssueProvider(&issues.LinearOptions{ServerURL: server.URL, APIKey: "lin_api_key", Teams: []string{"ENG"}})
+	require.NoError(t, err)
+	assert.NotZero(t, tracker.(*issues.LinearService).HTTPClient.Timeout, "requests time out")
+	assert.Equal(t, issues.Linear, issues.GetIssueProvider(tracker))
+
+	issue, err := tracker.GetIssue("ENG-123")
+	require.NoError(t, err)
+
+	assert.Equal(t, 123, issue.Number)
+	assert.Equal(t, "the login page is broken", issue.Title)
+	assert.Equal(t, "https://linear.app/example/issue/ENG-123/the-login-page-is-broken", issue.Link)
+	assert.Equal(t, "Done", issue.State)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, []string{"Bug", "Frontend"}, issue.Labels)
+	assert.Equal(t, time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC), issue.Created.UTC())
+	assert.Equal(t, "James Strachan", issue.Author.Name)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "rawlingsj", issue.Assignees[0].Login)
+
+	issue, err = tracker.GetIssue("ENG-999")
+	require.NoError(t, err)
+	assert.Nil(t, issue, "issues which do not exist are not found rather than failed lookups")
+}
+
+func TestLinearComments(t *testing.T) {
+	var added []string
+	server := linearServer(t, &added)
+	defer server.Close()
+
+	tracker, err := issues.NewLinearIssueProvider(&issues.LinearOptions{ServerURL: server.URL, APIKey: "lin_api_key", Teams: []string{"ENG"}})
+	require.NoError(t, err)
+
+	comments, err := tracker.(issues.IssueCommentLister).GetIssueComments("ENG-123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, comments)
+
+	err = tracker.CreateIssueComment("ENG-123", "Released in version 1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Released in version 1.2.0"}, added)
+}
+
+func TestLinearIssueKeyRegexp(t *testing.T) {
+	r := issues.LinearIssueKeyRegexp("ENG", "DES")
+	require.NotNil(t, r)
+	assert.Equal(t, []string{"ENG-123", "DES-4"}, r.FindAllString("fix: ENG-123 and DES-4 but not ABC-1 or UTF-8", -1))
+	assert.Nil(t, issues.LinearIssueKeyRegexp())
+}
@@ -1,6 +1,7 @@
 package issues
 
 import (
+	"errors"
 	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
@@ -29,11 +30,31 @@ type IssueProvider interface {
 	HomeURL() string
 }
 
+// IssueCommentLister is implemented by issue trackers which can list the comments of issues
+type IssueCommentLister interface {
+	// GetIssueComments returns the bodies of the comments on the given issue
+	GetIssueComments(key string) ([]string, error)
+}
+
+// ErrCommentsNotSupported is returned when the issue tracker of an issue can not list its comments
+var ErrCommentsNotSupported = errors.New("the issue tracker does not support listing comments")
+
 // GetIssueProvider returns the kind of issue provider
 func GetIssueProvider(tracker IssueProvider) string {
-	_, ok := tracker.(*JiraService)
-	if ok {
+	switch tracker.(type) {
+	case *JiraService:
 		return Jira
+	case *BugzillaService:
+		return Bugzilla
+	case *TrelloService:
+		return Trello
+	case *AzureService:
+		return Azure
+	case *LinearService:
+		return Linear
+	case *ExternalService:
+		return External
+	default:
+		return Git
 	}
-	return Git
 }
@@ -0,0 +1,149 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodyLength the maximum length of a response body included in an error
+const maxErrorBodyLength = 500
+
+// httpTimeout the maximum time of a request to the REST and GraphQL APIs of issue trackers
+const httpTimeout = 30 * time.Second
+
+// newHTTPClient creates the client of the REST and GraphQL APIs of issue trackers
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout}
+}
+
+// statusError an error status of a response together with its body
+type statusError struct {
+	StatusCode int
+	Body       []byte
+	message    string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// errorStatus returns the status of the response of the error or 0 if it is not an error status
+func errorStatus(err error) (int, []byte) {
+	var e *statusError
+	if errors.As(err, &e) {
+		return e.StatusCode, e.Body
+	}
+	return 0, nil
+}
+
+// doJSON sends a request with the JSON encoded body if it is not nil and decodes the JSON response into result if
+// it is not nil. The body is sent as application/json unless the header specifies another content type. Responses
+// with an error status are returned as an error including the response body.
+func doJSON(client *http.Client, method, u string, header http.Header, body, result interface{}) error {
+	return doJSONWithContext(context.Background(), client, method, u, header, body, result)
+}
+
+// doJSONWithContext sends the request like doJSON with the context
+func doJSONWithContext(ctx context.Context, client *http.Client, method, u string, header http.Header, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if client == nil {
+		client = newHTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		text := strings.TrimSpace(string(data))
+		if len(text) > maxErrorBodyLength {
+			text = text[:maxErrorBodyLength] + "..."
+		}
+		message := fmt.Sprintf("%s %s returned status %d", method, req.URL.Redacted(), resp.StatusCode)
+		if text != "" {
+			message += ": " + text
+		}
+		return &statusError{StatusCode: resp.StatusCode, Body: data, message: message}
+	}
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return fmt.Errorf("failed to parse response of %s %s: %w", method, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphQLError  `json:"errors"`
+}
+
+type graphQLError struct {
+	Message string        `json:"message"`
+	Type    string        `json:"type"`
+	Path    []interface{} `json:"path"`
+}
+
+// graphQLErrors the errors of a GraphQL response
+type graphQLErrors []graphQLError
+
+func (e graphQLErrors) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return fmt.Sprintf("GraphQL query failed: %s", strings.Join(messages, ", "))
+}
+
+// doGraphQL sends the GraphQL query with the variables and decodes the data of the response into result. Errors in
+// the response are returned as an error
+func doGraphQL(client *http.Client, u string, header http.Header, query string, variables map[string]interface{}, result interface{}) error {
+	resp := &graphQLResponse{}
+	err := doJSON(client, http.MethodPost, u, header, &graphQLRequest{Query: query, Variables: variables}, resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return graphQLErrors(resp.Errors)
+	}
+	if result == nil || len(resp.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, result)
+}
@@ -0,0 +1,297 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+)
+
+const (
+	// TrelloAPIURL the default URL of the Trello REST API
+	TrelloAPIURL = "https://api.trello.com"
+
+	// TrelloURL the URL of the Trello web site used to link to boards and cards
+	TrelloURL = "https://trello.com"
+)
+
+// TrelloService an issue provider for the cards of a Trello board. Cards are referenced by their short link or ID
+type TrelloService struct {
+	HTTPClient *http.Client
+	ServerURL  string
+	APIKey     string
+	Token      string
+	Board      string
+	List       string
+	DoneLists  []string
+}
+
+// TrelloOptions the settings to connect to a Trello board
+type TrelloOptions struct {
+	// ServerURL the URL of the Trello REST API. Defaults to TrelloAPIURL
+	ServerURL string
+
+	// APIKey the API key of the Trello power-up
+	APIKey string
+
+	// Token the token authorizing access to the boards of a user
+	Token string
+
+	// Board the short link or ID of the board whose cards are searched
+	Board string
+
+	// List the ID of the list new cards are created in
+	List string
+
+	// DoneLists the names of the lists whose cards are closed
+	DoneLists []string
+}
+
+type trelloLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type trelloMember struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	FullName   string `json:"fullName"`
+	AvatarURL  string `json:"avatarUrl"`
+	ProfileURL string `json:"url"`
+}
+
+type trelloCard struct {
+	ID               string         `json:"id"`
+	IDShort          int            `json:"idShort"`
+	ShortLink        string         `json:"shortLink"`
+	ShortURL         string         `json:"shortUrl"`
+	Name             string         `json:"name"`
+	Desc             string         `json:"desc"`
+	Closed           bool           `json:"closed"`
+	DateLastActivity time.Time      `json:"dateLastActivity"`
+	Labels           []trelloLabel  `json:"labels"`
+	Members          []trelloMember `json:"members"`
+	List             *struct {
+		Name string `json:"name"`
+	} `json:"list"`
+}
+
+type trelloAction struct {
+	Data struct {
+		Text string `json:"text"`
+	} `json:"data"`
+}
+
+// NewTrelloIssueProvider creates an issue provider for the cards of a Trello board
+func NewTrelloIssueProvider(o *TrelloOptions) (IssueProvider, error) {
+	if o.APIKey == "" || o.Token == "" {
+		return nil, fmt.Errorf("no Trello API key and token")
+	}
+	serverURL := o.ServerURL
+	if serverURL == "" {
+		serverURL = TrelloAPIURL
+	}
+	return &TrelloService{
+		HTTPClient: newHTTPClient(),
+		ServerURL:  strings.TrimSuffix(serverURL, "/"),
+		APIKey:     o.APIKey,
+		Token:      o.Token,
+		Board:      o.Board,
+		List:       o.List,
+		DoneLists:  o.DoneLists,
+	}, nil
+}
+
+func (i *TrelloService) GetIssue(key string) (*scm.Issue, error) {
+	params := url.Values{}
+	params.Set("members", "true")
+	params.Set("list", "true")
+	card := &trelloCard{}
+	err := i.do(http.MethodGet, "1/cards/"+url.PathEscape(key), params, nil, card)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find card %s: %w", key, err)
+	}
+	return i.trelloToGitIssue(card), nil
+}
+
+func (i *TrelloService) SearchIssues(query string) ([]*scm.Issue, error) {
+	cards, err := i.boardCards("open")
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(query)
+	var answer []*scm.Issue
+	for k := range cards {
+		card := &cards[k]
+		if query == "" || strings.Contains(strings.ToLower(card.Name+"\n"+card.Desc), query) {
+			answer = append(answer, i.trelloToGitIssue(card))
+		}
+	}
+	return answer, nil
+}
+
+func (i *TrelloService) SearchIssuesClosedSince(t time.Time) ([]*scm.Issue, error) {
+	cards, err := i.boardCards("all")
+	if err != nil {
+		return nil, err
+	}
+	var answer []*scm.Issue
+	for k := range cards {
+		issue := i.trelloToGitIssue(&cards[k])
+		if issue.Closed && !issue.Updated.Before(t) {
+			answer = append(answer, issue)
+		}
+	}
+	return answer, nil
+}
+
+func (i *TrelloService) boardCards(filter string) ([]trelloCard, error) {
+	if i.Board == "" {
+		return nil, fmt.Errorf("no Trello board configured")
+	}
+	params := url.Values{}
+	params.Set("members", "true")
+	params.Set("list", "true")
+	var cards []trelloCard
+	err := i.do(http.MethodGet, "1/boards/"+url.PathEscape(i.Board)+"/cards/"+filter, params, nil, &cards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cards of board %s: %w", i.Board, err)
+	}
+	return cards, nil
+}
+
+func (i *TrelloService) CreateIssue(issue *scm.Issue) (*scm.Issue, error) {
+	if i.List == "" {
+		return nil, fmt.Errorf("no Trello list configured to create cards in")
+	}
+	body := map[string]string{
+		"idList": i.List,
+		"name":   issue.Title,
+		"desc":   issue.Body,
+	}
+	card := &trelloCard{}
+	err := i.do(http.MethodPost, "1/cards", nil, body, card)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create card: %w", err)
+	}
+	return i.trelloToGitIssue(card), nil
+}
+
+func (i *TrelloService) CreateIssueComment(key, comment string) error {
+	params := url.Values{}
+	params.Set("text", comment)
+	err := i.do(http.MethodPost, "1/cards/"+url.PathEscape(key)+"/actions/comments", params, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add comment to card %s: %w", key, err)
+	}
+	return nil
+}
+
+func (i *TrelloService) GetIssueComments(key string) ([]string, error) {
+	params := url.Values{}
+	params.Set("filter", "commentCard")
+	params.Set("limit", "1000")
+	var actions []trelloAction
+	err := i.do(http.MethodGet, "1/cards/"+url.PathEscape(key)+"/actions", params, nil, &actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments of card %s: %w", key, err)
+	}
+	var answer []string
+	for _, a := range actions {
+		answer = append(answer, a.Data.Text)
+	}
+	return answer, nil
+}
+
+func (i *TrelloService) IssueURL(key string) string {
+	return stringhelpers.UrlJoin(TrelloURL, "c", key)
+}
+
+func (i *TrelloService) HomeURL() string {
+	if i.Board == "" {
+		return TrelloURL
+	}
+	return stringhelpers.UrlJoin(TrelloURL, "b", i.Board)
+}
+
+// trelloToGitIssue converts a card using the name of its list as the state. Archived cards and the cards in one of
+// the done lists are closed
+func (i *TrelloService) trelloToGitIssue(card *trelloCard) *scm.Issue {
+	answer := &scm.Issue{
+		Number:  card.IDShort,
+		Title:   card.Name,
+		Body:    card.Desc,
+		Link:    card.ShortURL,
+		State:   IssueStateOpen,
+		Closed:  card.Closed,
+		Updated: card.DateLastActivity,
+	}
+	if answer.Link == "" {
+		key := card.ShortLink
+		if key == "" {
+			key = card.ID
+		}
+		answer.Link = i.IssueURL(key)
+	}
+	if card.List != nil && card.List.Name != "" {
+		answer.State = card.List.Name
+		for _, l := range i.DoneLists {
+			if strings.EqualFold(l, card.List.Name) {
+				answer.Closed = true
+			}
+		}
+	}
+	if card.Closed && answer.State == IssueStateOpen {
+		answer.State = IssueStateClosed
+	}
+	if created, ok := trelloCreated(card.ID); ok {
+		answer.Created = created
+	}
+	for _, l := range card.Labels {
+		name := l.Name
+		if name == "" {
+			name = l.Color
+		}
+		if name != "" {
+			answer.Labels = append(answer.Labels, name)
+		}
+	}
+	for _, m := range card.Members {
+		answer.Assignees = append(answer.Assignees, scm.User{
+			Login:  m.Username,
+			Name:   m.FullName,
+			Avatar: m.AvatarURL,
+			Link:   m.ProfileURL,
+		})
+	}
+	return answer
+}
+
+// trelloCreated returns the creation time of a card which is encoded in the first 8 hex digits of its ID
+func trelloCreated(id string) (time.Time, bool) {
+	if len(id) < 8 {
+		return time.Time{}, false
+	}
+	var seconds int64
+	_, err := fmt.Sscanf(id[:8], "%x", &seconds)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
+
+// do invokes the REST API and decodes the JSON response into result if it is not nil. The API key and token are
+// sent in the Authorization header so that they don't end up in logs or errors
+func (i *TrelloService) do(method, path string, params url.Values, body, result interface{}) error {
+	u := stringhelpers.UrlJoin(i.ServerURL, path)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	header := http.Header{}
+	header.Set("Authorization", fmt.Sprintf(`OAuth oauth_consumer_key="%s", oauth_token="%s"`, i.APIKey, i.Token))
+	return doJSON(i.HTTPClient, method, u, header, body, result)
+}
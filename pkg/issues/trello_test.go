@@ -0,0 +1,102 @@
+//go:build unit
+
+package issues_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/issues"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const trelloCardJSON = `{
+  "id": "5fefa970c3a4c51d3e2c5f01",
+  "idShort": 42,
+  "shortLink": "AbCd1234",
+  "shortUrl": "https://trello.com/c/AbCd1234",
+  "name": "redesign the login page",
+  "desc": "make it pretty",
+  "closed": false,
+  "dateLastActivity": "2021-01-04T10:00:00.000Z",
+  "labels": [{"name": "design", "color": "green"}, {"name": "", "color": "red"}],
+  "members": [{"id": "1", "username": "jstrachan", "fullName": "James Strachan", "avatarUrl": "https://trello-members.s3.amazonaws.com/1"}],
+  "list": {"name": "Done"}
+}`
+
+func TestTrelloGetIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1/cards/AbCd1234", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("list"))
+		assert.Equal(t, `OAuth oauth_consumer_key="key", oauth_token="token"`, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(trelloCardJSON))
+	}))
+	defer server.Close()
+
+	tracker, err := issues.NewTrelloIssueProvider(&issues.TrelloOptions{
+		ServerURL: server.URL,
+		APIKey:    "key",
+		Token:     "token",
+		Board:     "XyZw9876",
+		DoneLists: []string{"done"},
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, tracker.(*issues.TrelloService).HTTPClient.Timeout, "requests time out")
+	assert.Equal(t, issues.Trello, issues.GetIssueProvider(tracker))
+	assert.Equal(t, "https://trello.com/b/XyZw9876", tracker.HomeURL())
+
+	issue, err := tracker.GetIssue("AbCd1234")
+	require.NoError(t, err)
+
+	assert.Equal(t, 42, issue.Number)
+	assert.Equal(t, "redesign the login page", issue.Title)
+	assert.Equal(t, "https://trello.com/c/AbCd1234", issue.Link)
+	assert.Equal(t, "Done", issue.State)
+	assert.True(t, issue.Closed)
+	assert.Equal(t, []string{"design", "red"}, issue.Labels)
+	assert.Equal(t, time.Date(2021, 1, 1, 23, 0, 0, 0, time.UTC), issue.Created)
+	require.Len(t, issue.Assignees, 1)
+	assert.Equal(t, "jstrachan", issue.Assignees[0].Login)
+	assert.Equal(t, "James Strachan", issue.Assignees[0].Name)
+}
+
+func TestTrelloComments(t *testing.T) {
+	var added []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /1/cards/AbCd1234/actions":
+			assert.Equal(t, "commentCard", r.URL.Query().Get("filter"))
+			_, _ = w.Write([]byte(`[{"data": {"text": "second"}}, {"data": {"text": "first"}}]`))
+		case "POST /1/cards/AbCd1234/actions/comments":
+			added = append(added, r.URL.Query().Get("text"))
+			_, _ = w.Write([]byte(`{"id": "1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`The requested resource was not found.`))
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := issues.NewTrelloIssueProvider(&issues.TrelloOptions{ServerURL: server.URL, APIKey: "key", Token: "token"})
+	require.NoError(t, err)
+
+	comments, err := tracker.(issues.IssueCommentLister).GetIssueComments("AbCd1234")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second", "first"}, comments)
+
+	err = tracker.CreateIssueComment("AbCd1234", "Released in version 1.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Released in version 1.2.0"}, added)
+
+	err = tracker.CreateIssueComment("Missing1", "hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+
+	_, err = issues.NewTrelloIssueProvider(&issues.TrelloOptions{Board: "XyZw9876"})
+	assert.Error(t, err)
+}
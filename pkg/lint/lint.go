@@ -0,0 +1,159 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+)
+
+const (
+	// RuleFormat the header must be a Conventional Commit
+	RuleFormat = "format"
+	// RuleType the type must be one of the allowed types
+	RuleType = "type"
+	// RuleScope the scope must be present or one of the allowed scopes
+	RuleScope = "scope"
+	// RuleHeaderLength the header must not be longer than the maximum length
+	RuleHeaderLength = "header-length"
+	// RuleIssue the message must reference an issue
+	RuleIssue = "issue"
+)
+
+// scissorsLine git drops everything below this line of a commit message being edited with --verbose
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// Rules the rules commit messages are checked against. The zero value only checks that the message is a
+// Conventional Commit.
+type Rules struct {
+	// Types the allowed commit types. Aliases of the changelog configuration are resolved. Any type is allowed if empty
+	Types []string `json:"types,omitempty"`
+
+	// Scopes the allowed scopes. Any scope is allowed if empty
+	Scopes []string `json:"scopes,omitempty"`
+
+	// RequireScope requires every commit to have a scope
+	RequireScope bool `json:"requireScope,omitempty"`
+
+	// MaxHeaderLength the maximum length of the first line of the message. Unlimited if zero
+	MaxHeaderLength int `json:"maxHeaderLength,omitempty"`
+
+	// RequireIssue requires every commit to reference an issue
+	RequireIssue bool `json:"requireIssue,omitempty"`
+}
+
+// Violation a rule which a commit message does not follow
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// Linter checks commit messages with the same parser used to generate the changelog
+type Linter struct {
+	Rules Rules
+
+	// Generator resolves the commit type aliases. Defaults to the default conventional commit groups
+	Generator *gits.Generator
+
+	// IssueRegexps the regular expressions of issue references used by the RequireIssue rule
+	IssueRegexps []*regexp.Regexp
+}
+
+// Lint returns the violations of the rules by the commit message
+func (l *Linter) Lint(message string) []Violation {
+	if l.Generator == nil {
+		l.Generator = gits.NewGenerator()
+	}
+	message = strings.TrimSpace(message)
+	header := strings.Split(message, "\n")[0]
+
+	var answer []Violation
+	if l.Rules.MaxHeaderLength > 0 && len(header) > l.Rules.MaxHeaderLength {
+		answer = append(answer, Violation{
+			Rule:    RuleHeaderLength,
+			Message: fmt.Sprintf("header is %d characters long but must not be longer than %d", len(header), l.Rules.MaxHeaderLength),
+		})
+	}
+	if l.Rules.RequireIssue && !l.hasIssue(message) {
+		answer = append(answer, Violation{
+			Rule:    RuleIssue,
+			Message: "message does not reference an issue",
+		})
+	}
+
+	matches := gits.ConventionalCommitRegexp.FindStringSubmatch(header)
+	if matches == nil {
+		return append([]Violation{{
+			Rule:    RuleFormat,
+			Message: "header is not formatted as '<type>(<scope>): <description>', see https://conventionalcommits.org/",
+		}}, answer...)
+	}
+	commitType, scope := matches[1], matches[2]
+	if len(l.Rules.Types) > 0 && !l.allowedType(commitType) {
+		answer = append(answer, Violation{
+			Rule:    RuleType,
+			Message: fmt.Sprintf("type %s is not one of: %s", commitType, strings.Join(l.Rules.Types, ", ")),
+		})
+	}
+	switch {
+	case scope == "" && l.Rules.RequireScope:
+		answer = append(answer, Violation{
+			Rule:    RuleScope,
+			Message: "scope is required",
+		})
+	case scope != "" && len(l.Rules.Scopes) > 0 && !contains(l.Rules.Scopes, scope):
+		answer = append(answer, Violation{
+			Rule:    RuleScope,
+			Message: fmt.Sprintf("scope %s is not one of: %s", scope, strings.Join(l.Rules.Scopes, ", ")),
+		})
+	}
+	return answer
+}
+
+func (l *Linter) allowedType(commitType string) bool {
+	t := l.Generator.CanonicalCommitType(commitType)
+	for _, allowed := range l.Rules.Types {
+		if l.Generator.CanonicalCommitType(allowed) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Linter) hasIssue(message string) bool {
+	for _, r := range l.IssueRegexps {
+		if r.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanMessage removes the comments git adds to the message file passed to a commit-msg hook
+func CleanMessage(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
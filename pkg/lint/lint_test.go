@@ -0,0 +1,113 @@
+//go:build unit
+
+package lint_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/gits"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/lint"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	generator := gits.NewGenerator()
+	generator.ConfigureCommitGroups([]gits.CommitGroupConfig{
+		{Type: "feat", Title: "Features", Aliases: []string{"feature"}},
+	}, nil)
+	issueRegexps := []*regexp.Regexp{regexp.MustCompile(`\B#\d+\b`), regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-\d+\b`)}
+
+	testCases := []struct {
+		name     string
+		rules    lint.Rules
+		message  string
+		expected []string
+	}{
+		{
+			name:    "valid",
+			message: "feat(cli): add a command",
+		},
+		{
+			name:     "not conventional",
+			message:  "added a command",
+			expected: []string{lint.RuleFormat},
+		},
+		{
+			name:    "allowed type",
+			rules:   lint.Rules{Types: []string{"feat", "fix"}},
+			message: "fix: a bug",
+		},
+		{
+			name:    "allowed alias",
+			rules:   lint.Rules{Types: []string{"feat", "fix"}},
+			message: "feature!: a breaking feature",
+		},
+		{
+			name:     "type not allowed",
+			rules:    lint.Rules{Types: []string{"feat", "fix"}},
+			message:  "chore: tidy",
+			expected: []string{lint.RuleType},
+		},
+		{
+			name:     "scope required",
+			rules:    lint.Rules{RequireScope: true},
+			message:  "fix: a bug",
+			expected: []string{lint.RuleScope},
+		},
+		{
+			name:     "scope not allowed",
+			rules:    lint.Rules{Scopes: []string{"cli"}},
+			message:  "fix(api): a bug",
+			expected: []string{lint.RuleScope},
+		},
+		{
+			name:     "header too long",
+			rules:    lint.Rules{MaxHeaderLength: 20},
+			message:  "fix: a bug with a very long description\n\nshort body",
+			expected: []string{lint.RuleHeaderLength},
+		},
+		{
+			name:    "git issue",
+			rules:   lint.Rules{RequireIssue: true},
+			message: "fix: a bug\n\nfixes #123",
+		},
+		{
+			name:    "jira issue",
+			rules:   lint.Rules{RequireIssue: true},
+			message: "fix: ABC-123 a bug",
+		},
+		{
+			name:     "multiple violations",
+			rules:    lint.Rules{RequireIssue: true, MaxHeaderLength: 10},
+			message:  "a bug fixed without an issue",
+			expected: []string{lint.RuleFormat, lint.RuleHeaderLength, lint.RuleIssue},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &lint.Linter{
+				Rules:        tc.rules,
+				Generator:    generator,
+				IssueRegexps: issueRegexps,
+			}
+			var rules []string
+			for _, v := range l.Lint(tc.message) {
+				rules = append(rules, v.Rule)
+			}
+			assert.Equal(t, tc.expected, rules)
+		})
+	}
+}
+
+func TestCleanMessage(t *testing.T) {
+	text := `fix: a bug
+
+fixes #123
+# Please enter the commit message for your changes. Lines starting
+# with '#' will be ignored.
+# ------------------------ >8 ------------------------
+diff --git a/file.txt b/file.txt
+`
+	assert.Equal(t, "fix: a bug\n\nfixes #123", lint.CleanMessage(text))
+}
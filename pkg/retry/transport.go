@@ -0,0 +1,192 @@
+package retry
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+const (
+	// DefaultMaxRetries the default number of times a request is retried
+	DefaultMaxRetries = 5
+
+	// DefaultMaxWait the default maximum time to wait before retrying a request
+	DefaultMaxWait = 5 * time.Minute
+
+	// DefaultMinBackoff the default time to wait before the first retry if the response does not say how long to wait
+	DefaultMinBackoff = time.Second
+)
+
+// Transport retries requests which were rate limited or failed with a server error.
+//
+// It waits for the time given by the Retry-After header or until the X-RateLimit-Reset time of exhausted rate
+// limits. Otherwise it backs off exponentially with jitter. Requests are not retried if they would have to wait
+// longer than MaxWait such as when the hourly rate limit is exhausted. Server errors and network errors are only
+// retried for GET and HEAD requests as other requests may have been processed.
+type Transport struct {
+	// Base the transport which sends the requests. Defaults to http.DefaultTransport
+	Base http.RoundTripper
+
+	// MaxRetries the maximum number of retries of a request
+	MaxRetries int
+
+	// MaxWait the maximum time to wait before a retry
+	MaxWait time.Duration
+
+	// MinBackoff the time to wait before the first retry if the response does not say how long to wait. Doubled for
+	// each further retry
+	MinBackoff time.Duration
+}
+
+// NewClient returns a copy of the client whose transport retries requests. Returns the client if maxRetries is
+// not positive
+func NewClient(client *http.Client, maxRetries int, maxWait time.Duration) *http.Client {
+	if maxRetries <= 0 {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	answer := *client
+	answer.Transport = &Transport{
+		Base:       client.Transport,
+		MaxRetries: maxRetries,
+		MaxWait:    maxWait,
+		MinBackoff: DefaultMinBackoff,
+	}
+	return &answer
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	maxWait := t.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultMaxWait
+	}
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+		resp, err := base.RoundTrip(r)
+		if attempt >= t.MaxRetries || !t.retryable(req, resp, err) {
+			return resp, err
+		}
+		wait := t.wait(resp, attempt, maxWait)
+		if wait > maxWait {
+			log.Logger().Debugf("not retrying %s %s as it would have to wait %s", req.Method, req.URL.Redacted(), wait)
+			return resp, err
+		}
+		if resp != nil {
+			log.Logger().Infof("retrying %s %s in %s as it returned status %d", req.Method, req.URL.Redacted(), wait.Round(time.Millisecond), resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			log.Logger().Infof("retrying %s %s in %s as it failed: %v", req.Method, req.URL.Redacted(), wait.Round(time.Millisecond), err)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable returns true if the request can be retried after the response or error
+func (t *Transport) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if err != nil {
+		return idempotent
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		// GitHub reports exhausted and secondary rate limits as forbidden
+		return resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent || resp.Header.Get("Retry-After") != ""
+	default:
+		return false
+	}
+}
+
+// wait returns the time to wait before the next attempt
+func (t *Transport) wait(resp *http.Response, attempt int, maxWait time.Duration) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return d
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				d := time.Until(time.Unix(reset, 0))
+				if d < 0 {
+					d = 0
+				}
+				// add some jitter so that concurrent requests don't all retry at the same time
+				return d + jitter(time.Second)
+			}
+		}
+	}
+	backoff := t.MinBackoff
+	if backoff <= 0 {
+		backoff = DefaultMinBackoff
+	}
+	for i := 0; i < attempt && backoff < maxWait; i++ {
+		backoff *= 2
+	}
+	if backoff > maxWait {
+		backoff = maxWait
+	}
+	return backoff/2 + jitter(backoff/2)
+}
+
+// retryAfter parses the Retry-After header which is either a number of seconds or an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// jitter returns a random duration up to d
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	//nolint:gosec // the jitter does not need to be cryptographically secure
+	return time.Duration(rand.Int63n(int64(d)))
+}
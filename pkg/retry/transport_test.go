@@ -0,0 +1,120 @@
+//go:build unit
+
+package retry_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer returns a server which responds with the statuses and headers in turn and then with 200
+func newServer(t *testing.T, responses []func(w http.ResponseWriter)) (*httptest.Server, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies = append(bodies, string(data))
+		if len(bodies) <= len(responses) {
+			responses[len(bodies)-1](w)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func respond(status int, header ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(status)
+	}
+}
+
+func newClient(maxRetries int) *http.Client {
+	return &http.Client{Transport: &retry.Transport{
+		MaxRetries: maxRetries,
+		MaxWait:    time.Minute,
+		MinBackoff: time.Millisecond,
+	}}
+}
+
+func TestTransportRetriesRateLimits(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Unix(), 10)
+	server, bodies := newServer(t, []func(w http.ResponseWriter){
+		respond(http.StatusTooManyRequests, "Retry-After", "0"),
+		respond(http.StatusForbidden, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset),
+		respond(http.StatusServiceUnavailable),
+	})
+
+	resp, err := newClient(5).Post(server.URL, "application/json", strings.NewReader(`{"query": "{}"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "server errors of POST requests are not retried")
+	assert.Equal(t, []string{`{"query": "{}"}`, `{"query": "{}"}`, `{"query": "{}"}`}, *bodies, "the body is sent again")
+
+	server, bodies = newServer(t, []func(w http.ResponseWriter){
+		respond(http.StatusTooManyRequests, "Retry-After", "0"),
+		respond(http.StatusBadGateway),
+	})
+	resp, err = newClient(5).Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, *bodies, 3)
+}
+
+func TestTransportGivesUp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response func(w http.ResponseWriter)
+		calls    int
+	}{
+		{
+			name:     "forbidden without rate limit",
+			response: respond(http.StatusForbidden),
+			calls:    1,
+		},
+		{
+			name:     "wait too long",
+			response: respond(http.StatusTooManyRequests, "Retry-After", "3600"),
+			calls:    1,
+		},
+		{
+			name:     "max retries",
+			response: respond(http.StatusTooManyRequests),
+			calls:    3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, bodies := newServer(t, []func(w http.ResponseWriter){tc.response, tc.response, tc.response, tc.response})
+			resp, err := newClient(2).Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+			assert.Len(t, *bodies, tc.calls)
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	client := &http.Client{Timeout: time.Minute}
+	assert.Same(t, client, retry.NewClient(client, 0, time.Minute), "retries are disabled")
+
+	answer := retry.NewClient(client, 3, time.Minute)
+	require.IsType(t, &retry.Transport{}, answer.Transport)
+	assert.Equal(t, time.Minute, answer.Timeout)
+	assert.Nil(t, client.Transport, "the client is not modified")
+}
@@ -4,20 +4,35 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
 
 	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 )
 
-// GitUserResolver allows git users to be converted to JayeX users
+// GitUserResolver allows git users to be converted to JayeX users. It is safe for concurrent use
 type GitUserResolver struct {
 	GitProvider *scm.Client
 	cache       UserDetailService
+
+	// Cache caches the users looked up on the git provider across runs. Optional
+	Cache *cache.Cache
+
+	lock   sync.Mutex
+	logins map[string]*loginResult
+}
+
+// loginResult the result of looking up a login on the git provider
+type loginResult struct {
+	user *scm.User
+	err  error
 }
 
 // GitSignatureAsUser resolves the signature to a JayeX User
@@ -74,13 +89,16 @@ func (r *GitUserResolver) Resolve(user *scm.User) (*jenkinsv1.UserDetails, error
 		return u, nil
 	}
 
-	scmUser, _, err := r.GitProvider.Users.FindLogin(ctx, user.Login)
-	if scmUser == nil || scmhelpers.IsScmNotFound(err) {
-		return nil, nil
-	}
+	scmUser, err := r.findLogin(ctx, user.Login)
 	if err != nil {
+		if scmhelpers.IsScmNotFound(err) {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to find user %s: %w", user.Login, err)
 	}
+	if scmUser == nil {
+		return nil, nil
+	}
 
 	u = r.GitUserToUser(scmUser)
 	login := scmUser.Login
@@ -97,6 +115,44 @@ func (r *GitUserResolver) Resolve(user *scm.User) (*jenkinsv1.UserDetails, error
 	return u, nil
 }
 
+// findLogin looks up the login on the git provider once and remembers the result so that resolving the same user
+// again does not call the git provider. Users which are found or do not exist are also kept in the Cache
+func (r *GitUserResolver) findLogin(ctx context.Context, login string) (*scm.User, error) {
+	r.lock.Lock()
+	result := r.logins[login]
+	r.lock.Unlock()
+	if result != nil {
+		return result.user, result.err
+	}
+	namespace := "users/" + r.GitProvider.BaseURL.String()
+	var scmUser *scm.User
+	found, err := r.Cache.Get(namespace, login, &scmUser)
+	if err != nil {
+		log.Logger().Debugf("failed to read user %s from the cache: %v", login, err)
+	}
+	if !found {
+		scmUser, _, err = r.GitProvider.Users.FindLogin(ctx, login)
+		if scmhelpers.IsScmNotFound(err) {
+			// some drivers return an empty user together with the error
+			scmUser = nil
+			err = nil
+		}
+		if err == nil {
+			cacheErr := r.Cache.Put(namespace, login, scmUser)
+			if cacheErr != nil {
+				log.Logger().Debugf("failed to cache user %s: %v", login, cacheErr)
+			}
+		}
+	}
+	r.lock.Lock()
+	if r.logins == nil {
+		r.logins = map[string]*loginResult{}
+	}
+	r.logins[login] = &loginResult{user: scmUser, err: err}
+	r.lock.Unlock()
+	return scmUser, err
+}
+
 /* TODO
 // UpdateUserFromPRAuthor will attempt to use the
 func (r *GitUserResolver) UpdateUserFromPRAuthor(author *jenkinsv1.User, pullRequest *scm.PullRequest,
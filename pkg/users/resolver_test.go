@@ -0,0 +1,70 @@
+//go:build unit
+
+package users_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/cache"
+	"github.com/jenkins-x-plugins/jx-changelog/pkg/users"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveCachesUnknownUsers(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/users/ghost", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+	scmClient, err := github.New(server.URL)
+	require.NoError(t, err)
+	userCache := cache.New(t.TempDir(), time.Hour, false)
+
+	for i := 0; i < 2; i++ {
+		resolver := &users.GitUserResolver{GitProvider: scmClient, Cache: userCache}
+		u, err := resolver.Resolve(&scm.User{Login: "ghost", Name: "Ghost"})
+		require.NoError(t, err)
+		assert.Nil(t, u, "unknown users are not resolved whether cached or not")
+	}
+	assert.Equal(t, 1, requests, "the unknown user is cached")
+
+	var cached *scm.User
+	found, err := userCache.Get("users/"+scmClient.BaseURL.String(), "ghost", &cached)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Nil(t, cached, "unknown users are cached as a negative result")
+}
+
+// failingUserService fails to find users without returning a user like some go-scm drivers
+type failingUserService struct {
+	scm.UserService
+}
+
+func (s *failingUserService) FindLogin(context.Context, string) (*scm.User, *scm.Response, error) {
+	return nil, &scm.Response{Status: http.StatusForbidden}, errors.New("403 API rate limit exceeded")
+}
+
+func TestResolveReportsFailedLookups(t *testing.T) {
+	baseURL, err := url.Parse("https://github.example.com/")
+	require.NoError(t, err)
+	scmClient := &scm.Client{BaseURL: baseURL, Users: &failingUserService{}}
+
+	resolver := &users.GitUserResolver{GitProvider: scmClient, Cache: cache.New(t.TempDir(), time.Hour, false)}
+	u, err := resolver.Resolve(&scm.User{Login: "jstrachan", Name: "James Strachan"})
+	require.Error(t, err, "users which could not be looked up are not treated as unknown")
+	assert.Nil(t, u)
+	assert.Contains(t, err.Error(), "failed to find user jstrachan")
+}
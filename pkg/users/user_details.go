@@ -1,16 +1,26 @@
 package users
 
 import (
+	"sync"
+
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/naming"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 )
 
+// UserDetailService an in memory cache of users which is safe for concurrent use
 type UserDetailService struct {
+	lock  sync.Mutex
 	cache map[string]*v1.UserDetails
 }
 
 func (s *UserDetailService) GetUser(login string) *v1.UserDetails {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.getUser(login)
+}
+
+func (s *UserDetailService) getUser(login string) *v1.UserDetails {
 	if s.cache == nil {
 		s.cache = map[string]*v1.UserDetails{}
 	}
@@ -26,8 +36,11 @@ func (s *UserDetailService) CreateOrUpdateUser(u *v1.UserDetails) error {
 
 	id := naming.ToValidName(u.Login)
 
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	// check for an existing user by email
-	existing := s.GetUser(id)
+	existing := s.getUser(id)
 	if existing == nil {
 		s.cache[id] = u
 		return nil